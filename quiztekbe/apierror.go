@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// Machine readable error codes. Clients should branch on these, the messages can be reworded.
+const (
+	codeBadRequest       = "bad_request"
+	codeUnauthorized     = "unauthorized"
+	codeForbidden        = "forbidden"
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeConflict         = "conflict"
+	codeTooLarge         = "payload_too_large"
+	codeValidation       = "validation_failed"
+	codeInternal         = "internal_error"
+	codeUnavailable      = "service_unavailable"
+)
+
+// API_Error is the body of every failed request. Handlers return one rather than writing the
+// response themselves, and errorHandler turns it into JSON, so the status, code and body can't
+// drift apart from one handler to the next.
+type API_Error struct {
+	Status  int           `json:"-"`
+	Code    string        `json:"code"`
+	Message string        `json:"error"`
+	Fields  []Field_Error `json:"fields,omitempty"`
+	Details any           `json:"details,omitempty"` // endpoint specific extras, e.g. the lines a text import choked on
+	cause   error         // logged for 5xx, never sent to the client
+}
+
+func (e *API_Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+func (e *API_Error) Unwrap() error {
+	return e.cause
+}
+
+// withDetails attaches endpoint specific data to the error body
+func (e *API_Error) withDetails(details any) *API_Error {
+	e.Details = details
+	return e
+}
+
+func newAPIError(status int, code, message string) *API_Error {
+	return &API_Error{Status: status, Code: code, Message: message}
+}
+
+func badRequest(message string) *API_Error {
+	return newAPIError(fiber.StatusBadRequest, codeBadRequest, message)
+}
+
+func unauthorized(message string) *API_Error {
+	return newAPIError(fiber.StatusUnauthorized, codeUnauthorized, message)
+}
+
+func forbidden(message string) *API_Error {
+	return newAPIError(fiber.StatusForbidden, codeForbidden, message)
+}
+
+func notFound(message string) *API_Error {
+	return newAPIError(fiber.StatusNotFound, codeNotFound, message)
+}
+
+func conflict(message string) *API_Error {
+	return newAPIError(fiber.StatusConflict, codeConflict, message)
+}
+
+// validationFailed is the 422 every validator failure goes out as, listing every
+// problem so the client can fix them all in one go.
+func validationFailed(message string, fields []Field_Error) *API_Error {
+	e := newAPIError(fiber.StatusUnprocessableEntity, codeValidation, message)
+	e.Fields = fields
+	return e
+}
+
+// internalError keeps err for the log and only tells the client what failed
+func internalError(message string, err error) *API_Error {
+	e := newAPIError(fiber.StatusInternalServerError, codeInternal, message)
+	e.cause = err
+	return e
+}
+
+// statusCodes names the statuses fiber itself can fail a request with
+var statusCodes = map[int]string{
+	fiber.StatusBadRequest:            codeBadRequest,
+	fiber.StatusUnauthorized:          codeUnauthorized,
+	fiber.StatusForbidden:             codeForbidden,
+	fiber.StatusNotFound:              codeNotFound,
+	fiber.StatusMethodNotAllowed:      codeMethodNotAllowed,
+	fiber.StatusConflict:              codeConflict,
+	fiber.StatusRequestEntityTooLarge: codeTooLarge,
+	fiber.StatusUnprocessableEntity:   codeValidation,
+	fiber.StatusServiceUnavailable:    codeUnavailable,
+}
+
+// errorHandler is fiber's ErrorHandler. Anything a handler returns ends up here: our own
+// API_Error as is, fiber's errors (unknown route, body too big, a recovered panic) with their
+// status, a pgx.ErrNoRows that slipped through as a 404, and everything else as a 500.
+func errorHandler(c *fiber.Ctx, err error) error {
+	var apiErr *API_Error
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &apiErr):
+	case errors.Is(err, pgx.ErrNoRows):
+		apiErr = notFound("Not found")
+	case errors.As(err, &fiberErr):
+		code, ok := statusCodes[fiberErr.Code]
+		if !ok {
+			code = codeInternal
+			if fiberErr.Code < 500 {
+				code = codeBadRequest
+			}
+		}
+		apiErr = newAPIError(fiberErr.Code, code, fiberErr.Message)
+	default:
+		apiErr = internalError("Internal server error", err)
+	}
+
+	if apiErr.Status >= 500 {
+		log.Printf("%s %s: %v", c.Method(), c.OriginalURL(), err)
+	}
+	return c.Status(apiErr.Status).JSON(apiErr)
+}
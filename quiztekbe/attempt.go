@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// deadlineGrace covers the round trip of an answer sent right before the deadline
+	deadlineGrace = 2 * time.Second
+
+	sweepInterval = 30 * time.Second
+)
+
+// Attempts only move forward: in_progress → submitted → graded.
+const (
+	attemptInProgress = "in_progress" // taking answers
+	attemptSubmitted  = "submitted"   // closed to answers, waiting on the grader
+	attemptGraded     = "graded"      // score and total are final
+)
+
+// errAttemptState means the attempt wasn't in the state the transition starts from,
+// usually because another request or the sweeper got there first.
+var errAttemptState = errors.New("illegal attempt state transition")
+
+// attemptState is what the answer and complete handlers need to know before touching an attempt.
+type attemptState struct {
+	Quiz_id uuid.UUID
+	Status  string
+	Expired bool // past the deadline plus grace
+}
+
+// lockAttempt reads the attempt's state and locks its row for the rest of the transaction.
+// Answers take a FOR SHARE lock so they can run side by side, while completing takes
+// FOR UPDATE and so waits for in-flight answers to land first.
+func lockAttempt(ctx context.Context, q dbtx, attemptID uuid.UUID, lock string) (attemptState, error) {
+	queryStr := `
+		SELECT quiz_id, status, deadline IS NOT NULL AND now() > deadline + $2 * interval '1 second'
+		FROM submission_attempts
+		WHERE attempt_id = $1
+	` + lock
+	var state attemptState
+	err := q.QueryRow(ctx, queryStr, attemptID, deadlineGrace.Seconds()).Scan(&state.Quiz_id, &state.Status, &state.Expired)
+	return state, err
+}
+
+// finishAttempt submits an in-progress attempt and grades it. An attempt finished after its
+// deadline is recorded as completed at the deadline, since nothing later than that counts.
+func finishAttempt(ctx context.Context, q dbtx, attemptID uuid.UUID) (float64, float64, error) {
+	queryStr := `
+		UPDATE submission_attempts
+		SET status = $2, completed_at = LEAST(now(), COALESCE(deadline, now()))
+		WHERE attempt_id = $1 AND status = $3
+	`
+	tag, err := q.Exec(ctx, queryStr, attemptID, attemptSubmitted, attemptInProgress)
+	if err != nil {
+		return 0, 0, err
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, 0, errAttemptState
+	}
+	return gradeAttempt(ctx, q, attemptID)
+}
+
+// sweepExpiredAttempts auto-completes attempts whose deadline passed without the client
+// calling CompleteAttempt, so the score still gets recorded, and grades attempts left
+// submitted but ungraded. It runs until ctx is done.
+func sweepExpiredAttempts(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// the first pass doesn't wait for the ticker, so attempts from before grading existed
+	// show up in results as soon as the server is up
+	for {
+		n, err := gradeSubmittedAttempts(ctx)
+		if err != nil {
+			log.Println("attempt sweeper:", err)
+		} else if n > 0 {
+			log.Printf("attempt sweeper: graded %d submitted attempts", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := sweepOnce(ctx)
+			if err != nil {
+				log.Println("attempt sweeper:", err)
+			} else if n > 0 {
+				log.Printf("attempt sweeper: completed %d expired attempts", n)
+			}
+		}
+	}
+}
+
+// sweepOnce finishes every expired attempt in one transaction. SKIP LOCKED lets several
+// backend replicas sweep at the same time without grading an attempt twice.
+func sweepOnce(ctx context.Context) (int, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	queryStr := `
+		SELECT attempt_id
+		FROM submission_attempts
+		WHERE status = $2
+		  AND deadline < now() - $1 * interval '1 second'
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, queryStr, deadlineGrace.Seconds(), attemptInProgress)
+	if err != nil {
+		return 0, err
+	}
+	var attemptIDs []uuid.UUID
+	for rows.Next() {
+		var attemptID uuid.UUID
+		if err := rows.Scan(&attemptID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		attemptIDs = append(attemptIDs, attemptID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, attemptID := range attemptIDs {
+		if _, _, err := finishAttempt(ctx, tx, attemptID); err != nil {
+			return 0, err
+		}
+	}
+	return len(attemptIDs), tx.Commit(ctx)
+}
+
+// gradeBatchSize caps how many attempts one grading transaction holds locks on
+const gradeBatchSize = 100
+
+// gradeSubmittedAttempts grades attempts stuck in submitted. Submitting and grading happen in
+// one transaction, so the only ones are those migration 0004 carried over from before attempts
+// had states, which were completed but never scored. It returns how many it graded. One that
+// can't be graded is logged and skipped, so it doesn't hold up the rest, and tried again on
+// the next run.
+func gradeSubmittedAttempts(ctx context.Context) (int, error) {
+	graded := 0
+	failed := []uuid.UUID{} // empty rather than nil, <> ALL(NULL) would match nothing
+	for {
+		n, batchFailed, err := gradeSubmittedBatch(ctx, failed)
+		graded += n - len(batchFailed)
+		failed = append(failed, batchFailed...)
+		if err != nil || n < gradeBatchSize {
+			return graded, err
+		}
+	}
+}
+
+// gradeSubmittedBatch grades up to gradeBatchSize submitted attempts that aren't in skip,
+// each under its own savepoint. It returns how many it picked up and the ones that failed.
+func gradeSubmittedBatch(ctx context.Context, skip []uuid.UUID) (int, []uuid.UUID, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	queryStr := `
+		SELECT attempt_id
+		FROM submission_attempts
+		WHERE status = $1 AND attempt_id <> ALL($3::uuid[])
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, queryStr, attemptSubmitted, gradeBatchSize, skip)
+	if err != nil {
+		return 0, nil, err
+	}
+	var attemptIDs []uuid.UUID
+	for rows.Next() {
+		var attemptID uuid.UUID
+		if err := rows.Scan(&attemptID); err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		attemptIDs = append(attemptIDs, attemptID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	var failed []uuid.UUID
+	for _, attemptID := range attemptIDs {
+		// Begin on a tx is a savepoint, a failed attempt only rolls back its own grading
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return 0, nil, err
+		}
+		if _, _, err := gradeAttempt(ctx, savepoint, attemptID); err != nil {
+			if rbErr := savepoint.Rollback(ctx); rbErr != nil {
+				return 0, nil, rbErr
+			}
+			log.Printf("attempt sweeper: skipping attempt %s, can't grade it: %v", attemptID, err)
+			failed = append(failed, attemptID)
+			continue
+		}
+		if err := savepoint.Commit(ctx); err != nil {
+			return 0, nil, err
+		}
+	}
+	return len(attemptIDs), failed, tx.Commit(ctx)
+}
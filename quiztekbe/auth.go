@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	sessionTTL        = 7 * 24 * time.Hour
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt ignores anything past 72 bytes
+
+	localsUser         = "user"
+	localsSessionToken = "session_token"
+)
+
+// newSessionToken returns a random bearer token and the hash that gets stored in sessions.
+func newSessionToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashSessionToken(token), nil
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// isAdmin reports whether email is one of ADMIN_EMAILS
+func isAdmin(email string) bool {
+	for _, admin := range strings.Split(config.AdminEmails, ",") {
+		if email != "" && normalizeEmail(admin) == email {
+			return true
+		}
+	}
+	return false
+}
+
+// createSession stores a new session for email and returns the raw token for the client.
+func createSession(ctx context.Context, email string) (string, time.Time, error) {
+	token, tokenHash, err := newSessionToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(sessionTTL)
+
+	queryStr := "INSERT INTO sessions (token_hash, email, expires_at) VALUES ($1, $2, $3)"
+	if _, err := db.Exec(ctx, queryStr, tokenHash, email, expiresAt); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// bearerToken pulls the token out of an "Authorization: Bearer <token>" header.
+func bearerToken(c *fiber.Ctx) string {
+	header := c.Get(fiber.HeaderAuthorization)
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// currentUser returns the user attached by Authenticate, if the request had a valid token.
+func currentUser(c *fiber.Ctx) (User, bool) {
+	user, ok := c.Locals(localsUser).(User)
+	return user, ok
+}
+
+// Authenticate looks up the session behind the Authorization header and stores the user
+// in c.Locals. Requests without the header pass through anonymously; a bad or expired
+// token is rejected so the client knows to log in again.
+func Authenticate(c *fiber.Ctx) error {
+	token := bearerToken(c)
+	if token == "" {
+		return c.Next()
+	}
+
+	queryStr := `
+		SELECT u.email, u.created_at
+		FROM sessions s
+		JOIN users u ON u.email = s.email
+		WHERE s.token_hash = $1 AND s.expires_at > now()
+	`
+	var user User
+	err := db.QueryRow(context.Background(), queryStr, hashSessionToken(token)).Scan(&user.Email, &user.Created_at)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return unauthorized("Invalid or expired token")
+		}
+		return internalError("Failed to check session", err)
+	}
+	user.Is_admin = isAdmin(user.Email)
+
+	c.Locals(localsUser, user)
+	c.Locals(localsSessionToken, token)
+	return c.Next()
+}
+
+// RequireAuth rejects requests that Authenticate didn't attach a user to.
+func RequireAuth(c *fiber.Ctx) error {
+	if _, ok := currentUser(c); !ok {
+		return unauthorized("Login required")
+	}
+	return c.Next()
+}
+
+// PostRegister godoc
+// @Summary      Register a new user
+// @Description  Create an account with an email and password, and log it in straight away.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      User_Credentials  true  "Email and password"
+// @Success      201   {object}  map[string]interface{}  "Token, expiry and user"
+// @Failure      400   {object}  API_Error  "Invalid email or password"
+// @Failure      409   {object}  API_Error  "Email already registered"
+// @Failure      500   {object}  API_Error  "Internal server error"
+// @Router       /auth/register [post]
+func PostRegister(c *fiber.Ctx) error {
+	var creds User_Credentials
+	if err := c.BodyParser(&creds); err != nil {
+		return badRequest("Cannot parse JSON")
+	}
+	email := normalizeEmail(creds.Email)
+	if email == "" || !strings.Contains(email, "@") {
+		return badRequest("Invalid email")
+	}
+	if len(creds.Password) < minPasswordLength || len(creds.Password) > maxPasswordLength {
+		return badRequest("Password must be between 8 and 72 characters")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(creds.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return internalError("Failed to hash password", err)
+	}
+
+	queryStr := `
+		INSERT INTO users (email, password_hash) VALUES ($1, $2)
+		ON CONFLICT (email) DO NOTHING
+		RETURNING created_at
+	`
+	user := User{Email: email, Is_admin: isAdmin(email)}
+	err = db.QueryRow(context.Background(), queryStr, email, string(hash)).Scan(&user.Created_at)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return conflict("Email already registered")
+		}
+		return internalError("Failed to create user", err)
+	}
+
+	token, expiresAt, err := createSession(context.Background(), email)
+	if err != nil {
+		return internalError("Failed to create session", err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{"token": token, "expires_at": expiresAt, "user": user})
+}
+
+// PostLogin godoc
+// @Summary      Log in
+// @Description  Exchange an email and password for a bearer token to send in the Authorization header.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      User_Credentials  true  "Email and password"
+// @Success      200   {object}  map[string]interface{}  "Token, expiry and user"
+// @Failure      400   {object}  API_Error  "Cannot parse JSON"
+// @Failure      401   {object}  API_Error  "Invalid email or password"
+// @Failure      500   {object}  API_Error  "Internal server error"
+// @Router       /auth/login [post]
+func PostLogin(c *fiber.Ctx) error {
+	var creds User_Credentials
+	if err := c.BodyParser(&creds); err != nil {
+		return badRequest("Cannot parse JSON")
+	}
+	email := normalizeEmail(creds.Email)
+
+	queryStr := "SELECT email, password_hash, created_at FROM users WHERE email = $1"
+	var user User
+	var passwordHash string
+	err := db.QueryRow(context.Background(), queryStr, email).Scan(&user.Email, &passwordHash, &user.Created_at)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return internalError("Failed to look up user", err)
+	}
+	// same message for unknown email and wrong password so emails can't be probed
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(creds.Password)) != nil {
+		return unauthorized("Invalid email or password")
+	}
+	user.Is_admin = isAdmin(user.Email)
+
+	// piggyback cleanup of old sessions on login instead of running a separate job
+	if _, err := db.Exec(context.Background(), "DELETE FROM sessions WHERE expires_at <= now()"); err != nil {
+		log.Println(err)
+	}
+
+	token, expiresAt, err := createSession(context.Background(), user.Email)
+	if err != nil {
+		return internalError("Failed to create session", err)
+	}
+
+	return c.JSON(fiber.Map{"token": token, "expires_at": expiresAt, "user": user})
+}
+
+// PostLogout godoc
+// @Summary      Log out
+// @Description  Invalidate the bearer token used for this request.
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]string  "Logged out"
+// @Failure      401  {object}  API_Error  "Login required"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /auth/logout [post]
+func PostLogout(c *fiber.Ctx) error {
+	token, _ := c.Locals(localsSessionToken).(string)
+
+	queryStr := "DELETE FROM sessions WHERE token_hash = $1"
+	if _, err := db.Exec(context.Background(), queryStr, hashSessionToken(token)); err != nil {
+		return internalError("Failed to log out", err)
+	}
+	return c.JSON(fiber.Map{"status": "logged out"})
+}
+
+// GetMe godoc
+// @Summary      Get the current user
+// @Description  Return the user that owns the bearer token.
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  User
+// @Failure      401  {object}  API_Error  "Login required"
+// @Router       /auth/me [get]
+func GetMe(c *fiber.Ctx) error {
+	user, _ := currentUser(c)
+	return c.JSON(user)
+}
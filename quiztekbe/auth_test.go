@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestIsAdmin(t *testing.T) {
+	saved := config
+	t.Cleanup(func() { config = saved })
+	config.AdminEmails = " Root@Example.com ,ops@example.com,"
+
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"root@example.com", true},
+		{"ops@example.com", true},
+		{"someone@example.com", false},
+		{"", false}, // the trailing comma mustn't make everyone without an email an admin
+	}
+	for _, tt := range tests {
+		if got := isAdmin(tt.email); got != tt.want {
+			t.Errorf("isAdmin(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
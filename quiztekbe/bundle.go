@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	bundleFormat  = "quiztek.quiz"
+	bundleVersion = 1
+)
+
+// quizQuestions returns all of a quiz's questions in position order.
+func quizQuestions(ctx context.Context, q dbtx, quizID uuid.UUID) ([]Question, error) {
+	queryStr := "SELECT " + questionColumns + " FROM questions WHERE quiz_id = $1 ORDER BY position"
+	rows, err := q.Query(ctx, queryStr, quizID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []Question{}
+	for rows.Next() {
+		var question Question
+		if err := scanQuestion(rows, &question); err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+	return questions, rows.Err()
+}
+
+// questionContent is the part of a question that gets copied, without its ids and position.
+func questionContent(question Question) Question_Update {
+	return Question_Update{
+		Type:            question.Type,
+		Message:         question.Message,
+		Choices:         question.Choices,
+		Answer_tf:       question.Answer_tf,
+		Correct_choice:  question.Correct_choice,
+		Correct_answers: question.Correct_answers,
+		Fib_options:     question.Fib_options,
+		Correct_choices: question.Correct_choices,
+		Scoring_mode:    question.Scoring_mode,
+		Points:          &question.Points,
+		Penalty:         &question.Penalty,
+	}
+}
+
+// validateBundle checks the bundle's header and every question, the latter prefixed
+// with their index so a bad import can be fixed in one go.
+func validateBundle(bundle Quiz_Bundle) []Field_Error {
+	if bundle.Format != bundleFormat {
+		return []Field_Error{{Field: "format", Message: fmt.Sprintf("format must be %q", bundleFormat)}}
+	}
+	if bundle.Version != bundleVersion {
+		return []Field_Error{{Field: "version", Message: fmt.Sprintf("version %d is not supported, expected %d", bundle.Version, bundleVersion)}}
+	}
+
+	errs := prefixFieldErrors("quiz", validateTimeLimit(bundle.Quiz.Time_limit))
+	for i, question := range bundle.Questions {
+		errs = append(errs, prefixFieldErrors(fmt.Sprintf("questions[%d]", i), validateQuestion(questionContent(question)))...)
+	}
+	return errs
+}
+
+// ExportQuiz godoc
+// @Summary      Export a quiz
+// @Description  Download a quiz and its questions, in order, as a versioned JSON bundle that POST /quiz/import accepts.
+// @Tags         quiz
+// @Produce      json
+// @Param        id   path      string  true  "Quiz ID"
+// @Success      200  {object}  Quiz_Bundle
+// @Failure      400  {object}  API_Error  "Invalid quiz ID"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /quiz/export/{id} [get]
+func ExportQuiz(c *fiber.Ctx) error {
+	quizIDStr := c.Params("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz ID")
+	}
+
+	// one snapshot, so the quiz and its questions agree even if someone is editing it
+	tx, err := db.BeginTx(context.Background(), pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return internalError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	bundle := Quiz_Bundle{Format: bundleFormat, Version: bundleVersion, Exported_at: time.Now().UTC()}
+	queryStr := "SELECT quiz_id, title, category, category_id, COALESCE(creator_email, ''), created_at, time_limit_seconds FROM quizzes WHERE quiz_id = $1"
+	quiz := &bundle.Quiz
+	err = tx.QueryRow(context.Background(), queryStr, quizID).Scan(&quiz.Quiz_id, &quiz.Title, &quiz.Category, &quiz.Category_id, &quiz.Creator_email, &quiz.Created_at, &quiz.Time_limit)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Quiz not found")
+		}
+		return internalError("Failed to fetch quiz", err)
+	}
+
+	bundle.Questions, err = quizQuestions(context.Background(), tx, quizID)
+	if err != nil {
+		return internalError("Failed to fetch questions", err)
+	}
+
+	c.Attachment(fmt.Sprintf("quiz-%s.json", quizID))
+	return c.JSON(bundle)
+}
+
+// ImportQuiz godoc
+// @Summary      Import a quiz
+// @Description  Create a new quiz owned by the caller from a JSON bundle made by GET /quiz/export. The quiz and questions get fresh IDs. If any question is invalid nothing is created and every problem is reported.
+// @Tags         quiz
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      Quiz_Bundle  true  "Exported quiz"
+// @Success      201   {object}  map[string]interface{}  "New quiz id and question count"
+// @Failure      400   {object}  API_Error  "Invalid JSON payload"
+// @Failure      401   {object}  API_Error  "Login required"
+// @Failure      422   {object}  API_Error  "Unsupported bundle or invalid questions"
+// @Failure      500   {object}  API_Error  "Internal server error"
+// @Router       /quiz/import [post]
+func ImportQuiz(c *fiber.Ctx) error {
+	user, _ := currentUser(c)
+
+	var bundle Quiz_Bundle
+	if err := c.BodyParser(&bundle); err != nil {
+		return badRequest("Cannot parse JSON")
+	}
+	if fieldErrs := validateBundle(bundle); len(fieldErrs) > 0 {
+		return validationFailed("Invalid quiz bundle", fieldErrs)
+	}
+
+	quizID, err := importQuiz(context.Background(), user.Email, bundle.Quiz, bundle.Questions)
+	if err != nil {
+		return internalError("Failed to import quiz", err)
+	}
+	return c.Status(201).JSON(fiber.Map{"message": "Quiz imported", "id": quizID, "questions": len(bundle.Questions)})
+}
+
+// importQuiz creates a quiz owned by email with the given questions in order, all in one
+// transaction. The questions must already be valid.
+func importQuiz(ctx context.Context, email string, quiz Quiz, questions []Question) (uuid.UUID, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// ids from another environment mean nothing here, the category goes by name, and
+	// one that doesn't exist here leaves the quiz uncategorised
+	categoryID, category, err := resolveCategory(ctx, tx, nil, quiz.Category)
+	if errors.Is(err, errCategoryNotFound) {
+		categoryID, category, err = nil, "", nil
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	queryStr := "INSERT INTO quizzes (title, category, category_id, creator_email, time_limit_seconds) VALUES ($1, $2, $3, $4, $5) RETURNING quiz_id"
+	var quizID uuid.UUID
+	if err := tx.QueryRow(ctx, queryStr, quiz.Title, category, categoryID, email, quiz.Time_limit).Scan(&quizID); err != nil {
+		return uuid.Nil, err
+	}
+	for i, question := range questions {
+		if _, err := insertQuestion(ctx, tx, quizID, i+1, questionContent(question)); err != nil {
+			return uuid.Nil, err
+		}
+	}
+	return quizID, tx.Commit(ctx)
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// errCategoryNotFound is a category in a quiz body that doesn't exist.
+var errCategoryNotFound = errors.New("category not found")
+
+// categorySubtree is a subquery of the category ids at or below the category whose
+// id or slug is %[1]s, for filters that should include subcategories.
+const categorySubtree = `(
+	WITH RECURSIVE tree AS (
+		SELECT category_id FROM categories WHERE category_id::text = %[1]s OR slug = category_slug(%[1]s)
+		UNION ALL
+		SELECT c.category_id FROM categories c JOIN tree ON c.parent_id = tree.category_id
+	)
+	SELECT category_id FROM tree
+)`
+
+// categoryColumns is what scanCategory expects, with every category's subtree in tree.
+const categoryColumns = `
+	c.category_id, c.slug, c.name, c.parent_id, c.created_by, c.created_at,
+	(SELECT COUNT(*) FROM quizzes q WHERE q.category_id = c.category_id),
+	(SELECT COUNT(*) FROM quizzes q JOIN tree t ON q.category_id = t.category_id WHERE t.root = c.category_id)
+`
+
+const categoryTree = `
+	WITH RECURSIVE tree AS (
+		SELECT category_id AS root, category_id FROM categories
+		UNION ALL
+		SELECT tree.root, c.category_id FROM categories c JOIN tree ON c.parent_id = tree.category_id
+	)
+`
+
+func scanCategory(row pgx.Row, category *Category) error {
+	return row.Scan(&category.Category_id, &category.Slug, &category.Name, &category.Parent_id, &category.Created_by, &category.Created_at,
+		&category.Quiz_count, &category.Total_quiz_count)
+}
+
+// resolveCategory turns the category a quiz body names into an id and the name stored
+// next to it on quizzes. Both the id and the category text have to name an existing
+// category, the text by id or by slug, so "Math" finds the category with slug "math".
+// Categories are only made through POST /category. Neither gives an uncategorised quiz.
+func resolveCategory(ctx context.Context, q dbtx, categoryID *uuid.UUID, category string) (*uuid.UUID, string, error) {
+	var id uuid.UUID
+	var name string
+	var err error
+	switch category = strings.TrimSpace(category); {
+	case categoryID != nil:
+		err = q.QueryRow(ctx, "SELECT category_id, name FROM categories WHERE category_id = $1", *categoryID).Scan(&id, &name)
+	case category != "":
+		queryStr := "SELECT category_id, name FROM categories WHERE category_id::text = $1 OR slug = category_slug($1)"
+		err = q.QueryRow(ctx, queryStr, category).Scan(&id, &name)
+	default:
+		return nil, "", nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", errCategoryNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &id, name, nil
+}
+
+// categoryConflict maps constraint violations from writing a category to a response, or
+// returns nil when err isn't one.
+func categoryConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+	switch {
+	case pgErr.ConstraintName == "categories_slug_key":
+		return conflict("A category with this slug already exists")
+	case pgErr.ConstraintName == "categories_slug_valid":
+		return validationFailed("Invalid category", []Field_Error{{Field: "slug", Message: "slug needs at least one letter or digit"}})
+	case pgErr.Code == "23503": // foreign_key_violation on parent_id
+		return validationFailed("Invalid category", []Field_Error{{Field: "parent_id", Message: "parent category not found"}})
+	}
+	return nil
+}
+
+// categoryFailed answers a resolveCategory error from a quiz handler, blaming whichever
+// of category_id and category was used.
+func categoryFailed(err error, categoryID *uuid.UUID) error {
+	if errors.Is(err, errCategoryNotFound) {
+		field := "category"
+		if categoryID != nil {
+			field = "category_id"
+		}
+		return validationFailed("Invalid quiz", []Field_Error{{Field: field, Message: "category not found, create it first"}})
+	}
+	return internalError("Failed to resolve category", err)
+}
+
+func validateCategory(categoryPost Category_Post) []Field_Error {
+	if strings.TrimSpace(categoryPost.Name) == "" {
+		return []Field_Error{{Field: "name", Message: "name is required"}}
+	}
+	return nil
+}
+
+// validateCategoryPatch checks the fields a PATCH sends and returns the parent to move
+// the category under, nil when it isn't moved or goes to the top level.
+func validateCategoryPatch(categoryPatch Category_Patch) (*uuid.UUID, []Field_Error) {
+	var fieldErrs []Field_Error
+	if categoryPatch.Name != nil && strings.TrimSpace(*categoryPatch.Name) == "" {
+		fieldErrs = append(fieldErrs, Field_Error{Field: "name", Message: "name can't be empty"})
+	}
+	var parentID *uuid.UUID
+	if categoryPatch.Parent_id != nil && *categoryPatch.Parent_id != "" {
+		id, err := uuid.Parse(*categoryPatch.Parent_id)
+		if err != nil {
+			fieldErrs = append(fieldErrs, Field_Error{Field: "parent_id", Message: "parent_id must be a category id"})
+		} else {
+			parentID = &id
+		}
+	}
+	return parentID, fieldErrs
+}
+
+// authorizeCategory checks the logged in user made the category or is an admin.
+// A non-nil error is the response to send, the handler should return it as is.
+func authorizeCategory(c *fiber.Ctx, categoryID uuid.UUID) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return unauthorized("Login required")
+	}
+
+	var createdBy *string
+	err := db.QueryRow(context.Background(), "SELECT created_by FROM categories WHERE category_id = $1", categoryID).Scan(&createdBy)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Category not found")
+		}
+		return internalError("Failed to check permissions", err)
+	}
+
+	if !user.Is_admin && (createdBy == nil || *createdBy != user.Email) {
+		return forbidden("Only the category's creator or an admin can change it")
+	}
+	return nil
+}
+
+// GetCategories godoc
+// @Summary      List categories
+// @Description  List every category by name, with how many quizzes are in it directly and including its subcategories.
+// @Tags         category
+// @Produce      json
+// @Success      200  {array}   Category
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /category [get]
+func GetCategories(c *fiber.Ctx) error {
+	queryStr := categoryTree + "SELECT " + categoryColumns + " FROM categories c ORDER BY c.name, c.slug"
+	rows, err := db.Query(context.Background(), queryStr)
+	if err != nil {
+		return internalError("Failed to fetch categories", err)
+	}
+	defer rows.Close()
+
+	categories := []Category{}
+	for rows.Next() {
+		var category Category
+		if err := scanCategory(rows, &category); err != nil {
+			return internalError("Failed to scan category", err)
+		}
+		categories = append(categories, category)
+	}
+	return c.JSON(categories)
+}
+
+// GetCategory godoc
+// @Summary      Get a category
+// @Description  Get one category by its ID or slug.
+// @Tags         category
+// @Produce      json
+// @Param        id   path      string  true  "Category ID or slug"
+// @Success      200  {object}  Category
+// @Failure      404  {object}  API_Error  "Category not found"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /category/{id} [get]
+func GetCategory(c *fiber.Ctx) error {
+	queryStr := categoryTree + "SELECT " + categoryColumns + " FROM categories c WHERE c.category_id::text = $1 OR c.slug = $1"
+	var category Category
+	if err := scanCategory(db.QueryRow(context.Background(), queryStr, c.Params("id")), &category); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Category not found")
+		}
+		return internalError("Failed to fetch category", err)
+	}
+	return c.JSON(category)
+}
+
+// PostCategory godoc
+// @Summary      Create a category
+// @Description  Create a category, optionally under a parent. The slug is derived from the name unless given. The caller is recorded as its creator, who can change or delete it later.
+// @Tags         category
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      Category_Post  true  "Category to create"
+// @Success      201   {object}  map[string]interface{}  "New category id and slug"
+// @Failure      400   {object}  API_Error  "Invalid JSON payload"
+// @Failure      401   {object}  API_Error  "Login required"
+// @Failure      409   {object}  API_Error  "Slug already taken"
+// @Failure      422   {object}  API_Error  "Invalid name, slug or parent"
+// @Failure      500   {object}  API_Error  "Internal server error"
+// @Router       /category/create [post]
+func PostCategory(c *fiber.Ctx) error {
+	user, _ := currentUser(c)
+
+	var categoryPost Category_Post
+	if err := c.BodyParser(&categoryPost); err != nil {
+		return badRequest("Cannot parse JSON")
+	}
+	if fieldErrs := validateCategory(categoryPost); len(fieldErrs) > 0 {
+		return validationFailed("Invalid category", fieldErrs)
+	}
+
+	queryStr := `
+		INSERT INTO categories (slug, name, parent_id, created_by)
+		VALUES (category_slug(COALESCE(NULLIF($1, ''), $2)), $2, $3, $4)
+		RETURNING category_id, slug
+	`
+	var categoryID uuid.UUID
+	var slug string
+	err := db.QueryRow(context.Background(), queryStr, categoryPost.Slug, strings.TrimSpace(categoryPost.Name), categoryPost.Parent_id, user.Email).
+		Scan(&categoryID, &slug)
+	if err != nil {
+		if conflictErr := categoryConflict(err); conflictErr != nil {
+			return conflictErr
+		}
+		return internalError("Failed to create category", err)
+	}
+	return c.Status(201).JSON(fiber.Map{"message": "Category added", "id": categoryID, "slug": slug})
+}
+
+// PatchCategory godoc
+// @Summary      Update a category
+// @Description  Rename a category, change its slug or move it under another parent. Only the fields that are sent change, a parent_id of "" moves it to the top level. Quizzes in it pick up the new name. Only the category's creator or an admin can change it.
+// @Tags         category
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string          true  "Category ID"
+// @Param        body  body      Category_Patch  true  "Fields to change"
+// @Success      200   {object}  Category
+// @Failure      400   {object}  API_Error  "Invalid category ID or JSON payload"
+// @Failure      401   {object}  API_Error  "Login required"
+// @Failure      403   {object}  API_Error  "Not the category's creator or an admin"
+// @Failure      404   {object}  API_Error  "Category not found"
+// @Failure      409   {object}  API_Error  "Slug already taken"
+// @Failure      422   {object}  API_Error  "Invalid name, slug or parent, or the parent is inside this category"
+// @Failure      500   {object}  API_Error  "Internal server error"
+// @Router       /category/edit/{id} [patch]
+func PatchCategory(c *fiber.Ctx) error {
+	categoryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return badRequest("Invalid category ID")
+	}
+	if err := authorizeCategory(c, categoryID); err != nil {
+		return err
+	}
+	var categoryPatch Category_Patch
+	if err := c.BodyParser(&categoryPatch); err != nil {
+		return badRequest("Cannot parse JSON")
+	}
+	parentID, fieldErrs := validateCategoryPatch(categoryPatch)
+	if len(fieldErrs) > 0 {
+		return validationFailed("Invalid category", fieldErrs)
+	}
+	var name *string
+	if categoryPatch.Name != nil {
+		trimmed := strings.TrimSpace(*categoryPatch.Name)
+		name = &trimmed
+	}
+
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		return internalError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	if parentID != nil {
+		// two moves at once could otherwise each pass the check and make a loop together
+		if _, err := tx.Exec(context.Background(), "LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+			return internalError("Failed to lock categories", err)
+		}
+		queryStr := fmt.Sprintf("SELECT $2 IN %s", fmt.Sprintf(categorySubtree, "$1::text"))
+		var cycle bool
+		if err := tx.QueryRow(context.Background(), queryStr, categoryID.String(), *parentID).Scan(&cycle); err != nil {
+			return internalError("Failed to check parent category", err)
+		}
+		if cycle {
+			return validationFailed("Invalid category", []Field_Error{{Field: "parent_id", Message: "a category can't be moved inside itself"}})
+		}
+	}
+
+	// NULL for name or slug keeps them, an empty slug derives it from the (new) name again
+	queryStr := `
+		UPDATE categories
+		SET name = COALESCE($2, name),
+			slug = CASE WHEN $3::text IS NULL THEN slug ELSE category_slug(COALESCE(NULLIF($3, ''), $2, name)) END,
+			parent_id = CASE WHEN $4::bool THEN $5::uuid ELSE parent_id END
+		WHERE category_id = $1
+		RETURNING name
+	`
+	var newName string
+	err = tx.QueryRow(context.Background(), queryStr, categoryID, name, categoryPatch.Slug, categoryPatch.Parent_id != nil, parentID).Scan(&newName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// deleted between the permission check and the update
+			return notFound("Category not found")
+		}
+		if conflictErr := categoryConflict(err); conflictErr != nil {
+			return conflictErr
+		}
+		return internalError("Failed to update category", err)
+	}
+
+	queryStr = "UPDATE quizzes SET category = $2 WHERE category_id = $1 AND category <> $2"
+	if _, err := tx.Exec(context.Background(), queryStr, categoryID, newName); err != nil {
+		return internalError("Failed to update quizzes", err)
+	}
+
+	var category Category
+	queryStr = categoryTree + "SELECT " + categoryColumns + " FROM categories c WHERE c.category_id = $1"
+	if err := scanCategory(tx.QueryRow(context.Background(), queryStr, categoryID), &category); err != nil {
+		return internalError("Failed to fetch category", err)
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return internalError("Failed to commit transaction", err)
+	}
+	return c.JSON(category)
+}
+
+// DeleteCategory godoc
+// @Summary      Delete a category
+// @Description  Delete a category that has no subcategories. Its quizzes move up to the parent category, or become uncategorised at the top level. Only the category's creator or an admin can delete it.
+// @Tags         category
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Category ID"
+// @Success      200  {object}  map[string]string  "Category deleted"
+// @Failure      400  {object}  API_Error  "Invalid category ID"
+// @Failure      401  {object}  API_Error  "Login required"
+// @Failure      403  {object}  API_Error  "Not the category's creator or an admin"
+// @Failure      404  {object}  API_Error  "Category not found"
+// @Failure      409  {object}  API_Error  "Category still has subcategories"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /category/delete/{id} [delete]
+func DeleteCategory(c *fiber.Ctx) error {
+	categoryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return badRequest("Invalid category ID")
+	}
+	if err := authorizeCategory(c, categoryID); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		return internalError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	// quizzes go to the parent, COALESCEs leave them uncategorised when there's none
+	queryStr := `
+		UPDATE quizzes q
+		SET category_id = c.parent_id, category = COALESCE(p.name, '')
+		FROM categories c
+		LEFT JOIN categories p ON p.category_id = c.parent_id
+		WHERE c.category_id = $1 AND q.category_id = c.category_id
+	`
+	if _, err := tx.Exec(context.Background(), queryStr, categoryID); err != nil {
+		return internalError("Failed to move quizzes", err)
+	}
+
+	tag, err := tx.Exec(context.Background(), "DELETE FROM categories WHERE category_id = $1", categoryID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation from a child's parent_id
+			return conflict("Category still has subcategories")
+		}
+		return internalError("Failed to delete category", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return notFound("Category not found")
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return internalError("Failed to commit transaction", err)
+	}
+	return c.JSON(fiber.Map{"message": "Category deleted", "id": categoryID})
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateCategoryPatch(t *testing.T) {
+	parent := uuid.New()
+	tests := []struct {
+		name       string
+		patch      Category_Patch
+		wantParent *uuid.UUID
+		wantFields []string
+	}{
+		{"nothing sent", Category_Patch{}, nil, nil},
+		{"rename", Category_Patch{Name: ptr("Algebra")}, nil, nil},
+		{"new slug only", Category_Patch{Slug: ptr("alg")}, nil, nil},
+		{"move under parent", Category_Patch{Parent_id: ptr(parent.String())}, &parent, nil},
+		{"move to top level", Category_Patch{Parent_id: ptr("")}, nil, nil},
+
+		{"blank name", Category_Patch{Name: ptr("  ")}, nil, []string{"name"}},
+		{"parent not an id", Category_Patch{Parent_id: ptr("math")}, nil, []string{"parent_id"}},
+		{"both wrong", Category_Patch{Name: ptr(""), Parent_id: ptr("x")}, nil, []string{"name", "parent_id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parentID, errs := validateCategoryPatch(tt.patch)
+			if (parentID == nil) != (tt.wantParent == nil) || (parentID != nil && *parentID != *tt.wantParent) {
+				t.Errorf("parent = %v, want %v", parentID, tt.wantParent)
+			}
+			if len(errs) != len(tt.wantFields) {
+				t.Fatalf("errors = %+v, want fields %v", errs, tt.wantFields)
+			}
+			for i, e := range errs {
+				if e.Field != tt.wantFields[i] {
+					t.Errorf("errors[%d].Field = %q, want %q", i, e.Field, tt.wantFields[i])
+				}
+			}
+		})
+	}
+}
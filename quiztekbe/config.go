@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Config is everything the server reads at startup. Values come from the environment, then
+// from the optional CONFIG_FILE (KEY=VALUE lines, same format as .env), then the defaults below.
+// The environment wins so docker compose can override a shared file per service.
+type Config struct {
+	ListenAddr  string
+	CORSOrigins string // comma separated, "*" allows any origin
+	AutoMigrate bool
+	AdminEmails string // comma separated, admins own every quiz and category
+
+	DatabaseURL       string
+	DBMaxConns        int32 // 0 keeps pgx's default
+	DBMinConns        int32
+	DBMaxConnLifetime time.Duration
+	DBMaxConnIdleTime time.Duration
+	DBConnectTimeout  time.Duration
+	DBStartupTimeout  time.Duration // how long startup keeps retrying an unreachable database
+
+	BodyLimit       int           // bytes, bigger request bodies get a 413
+	ReadTimeout     time.Duration // 0 means no timeout
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration // how long requests in flight get to finish after SIGTERM
+}
+
+var config Config
+
+// configSource looks keys up in the environment first, then in the config file
+type configSource struct {
+	file map[string]string
+	errs []string
+}
+
+func (s *configSource) lookup(key string) (string, bool) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true
+	}
+	value, ok := s.file[key]
+	return value, ok
+}
+
+func (s *configSource) string(key, fallback string) string {
+	if value, ok := s.lookup(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (s *configSource) int32(key string, fallback int32) int32 {
+	value, ok := s.lookup(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	n, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || n < 0 {
+		s.errs = append(s.errs, fmt.Sprintf("%s must be a non-negative number, got %q", key, value))
+		return fallback
+	}
+	return int32(n)
+}
+
+func (s *configSource) duration(key string, fallback time.Duration) time.Duration {
+	value, ok := s.lookup(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		s.errs = append(s.errs, fmt.Sprintf("%s must be a duration like 30s or 5m, got %q", key, value))
+		return fallback
+	}
+	return d
+}
+
+func (s *configSource) bool(key string, fallback bool) bool {
+	value, ok := s.lookup(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Sprintf("%s must be true or false, got %q", key, value))
+		return fallback
+	}
+	return b
+}
+
+// loadConfig reports every bad value at once rather than stopping at the first
+func loadConfig() (Config, error) {
+	source := configSource{}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		file, err := godotenv.Read(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("reading config file: %w", err)
+		}
+		source.file = file
+	}
+
+	cfg := Config{
+		ListenAddr:  source.string("LISTEN_ADDR", ":8080"),
+		CORSOrigins: source.string("CORS_ORIGINS", "*"),
+		AutoMigrate: source.bool("AUTO_MIGRATE", true),
+		AdminEmails: source.string("ADMIN_EMAILS", ""),
+
+		DatabaseURL:       source.string("DATABASE_URL", ""),
+		DBMaxConns:        source.int32("DB_MAX_CONNS", 0),
+		DBMinConns:        source.int32("DB_MIN_CONNS", 0),
+		DBMaxConnLifetime: source.duration("DB_MAX_CONN_LIFETIME", time.Hour),
+		DBMaxConnIdleTime: source.duration("DB_MAX_CONN_IDLE_TIME", 30*time.Minute),
+		DBConnectTimeout:  source.duration("DB_CONNECT_TIMEOUT", 5*time.Second),
+		DBStartupTimeout:  source.duration("DB_STARTUP_TIMEOUT", time.Minute),
+
+		// room for the largest QTI package plus the multipart framing around it
+		BodyLimit:       int(source.int32("BODY_LIMIT", qtiMaxPackageSize+1<<20)),
+		ReadTimeout:     source.duration("READ_TIMEOUT", 15*time.Second),
+		WriteTimeout:    source.duration("WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:     source.duration("IDLE_TIMEOUT", 60*time.Second),
+		ShutdownTimeout: source.duration("SHUTDOWN_TIMEOUT", 10*time.Second),
+	}
+
+	if cfg.DatabaseURL == "" {
+		source.errs = append(source.errs, "DATABASE_URL must be set")
+	}
+	if cfg.DBMaxConns > 0 && cfg.DBMinConns > cfg.DBMaxConns {
+		source.errs = append(source.errs, "DB_MIN_CONNS can't be more than DB_MAX_CONNS")
+	}
+	if cfg.BodyLimit == 0 {
+		source.errs = append(source.errs, "BODY_LIMIT must be more than 0")
+	}
+	if len(source.errs) > 0 {
+		return Config{}, fmt.Errorf("invalid config: %s", strings.Join(source.errs, "; "))
+	}
+	return cfg, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearConfigEnv unsets every variable loadConfig reads for the rest of the test, so the
+// machine running the tests can't leak its own settings in
+func clearConfigEnv(t *testing.T) {
+	for _, key := range []string{
+		"CONFIG_FILE", "LISTEN_ADDR", "CORS_ORIGINS", "AUTO_MIGRATE", "ADMIN_EMAILS",
+		"DATABASE_URL", "DB_MAX_CONNS", "DB_MIN_CONNS", "DB_MAX_CONN_LIFETIME", "DB_MAX_CONN_IDLE_TIME",
+		"DB_CONNECT_TIMEOUT", "DB_STARTUP_TIMEOUT",
+		"BODY_LIMIT", "READ_TIMEOUT", "WRITE_TIMEOUT", "IDLE_TIMEOUT", "SHUTDOWN_TIMEOUT",
+	} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "quiztek.env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("DATABASE_URL", "postgres://localhost/quiztek")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{
+		ListenAddr:        ":8080",
+		CORSOrigins:       "*",
+		AutoMigrate:       true,
+		DatabaseURL:       "postgres://localhost/quiztek",
+		DBMaxConnLifetime: time.Hour,
+		DBMaxConnIdleTime: 30 * time.Minute,
+		DBConnectTimeout:  5 * time.Second,
+		DBStartupTimeout:  time.Minute,
+		BodyLimit:         21 << 20,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+		ShutdownTimeout:   10 * time.Second,
+	}
+	if cfg != want {
+		t.Errorf("got  %+v\nwant %+v", cfg, want)
+	}
+}
+
+func TestLoadConfigFileAndEnv(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, strings.Join([]string{
+		"# shared settings",
+		"DATABASE_URL=postgres://db/quiztek",
+		"LISTEN_ADDR=:9000",
+		"DB_MAX_CONNS=10",
+		"AUTO_MIGRATE=false",
+		"READ_TIMEOUT=5s",
+	}, "\n")))
+	t.Setenv("LISTEN_ADDR", ":7000")
+	t.Setenv("READ_TIMEOUT", "0")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DatabaseURL != "postgres://db/quiztek" || cfg.DBMaxConns != 10 || cfg.AutoMigrate {
+		t.Errorf("file values not used: %+v", cfg)
+	}
+	if cfg.ListenAddr != ":7000" {
+		t.Errorf("ListenAddr = %q, want the environment to win over the file", cfg.ListenAddr)
+	}
+	if cfg.ReadTimeout != 0 {
+		t.Errorf("ReadTimeout = %v, want 0 from the environment", cfg.ReadTimeout)
+	}
+}
+
+func TestLoadConfigEmptyEnvFallsBack(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, "DATABASE_URL=postgres://db/quiztek\nLISTEN_ADDR=:9000\n"))
+	t.Setenv("LISTEN_ADDR", "")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ListenAddr != ":8080" {
+		t.Errorf("ListenAddr = %q, want an empty variable to mean the default", cfg.ListenAddr)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("DB_MAX_CONNS", "-1")
+	t.Setenv("DB_STARTUP_TIMEOUT", "soon")
+	t.Setenv("AUTO_MIGRATE", "maybe")
+	t.Setenv("BODY_LIMIT", "0")
+
+	_, err := loadConfig()
+	if err == nil {
+		t.Fatal("want an error")
+	}
+	for _, want := range []string{"DATABASE_URL must be set", "DB_MAX_CONNS", "DB_STARTUP_TIMEOUT", "AUTO_MIGRATE", "BODY_LIMIT"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %s", err, want)
+		}
+	}
+}
+
+func TestLoadConfigConnLimits(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("DATABASE_URL", "postgres://localhost/quiztek")
+	t.Setenv("DB_MAX_CONNS", "2")
+	t.Setenv("DB_MIN_CONNS", "5")
+
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "DB_MIN_CONNS") {
+		t.Errorf("error = %v, want DB_MIN_CONNS over DB_MAX_CONNS rejected", err)
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.env"))
+
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "reading config file") {
+		t.Errorf("error = %v, want the missing file reported", err)
+	}
+}
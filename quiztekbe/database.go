@@ -2,28 +2,80 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"log"
-	"os"
+	"time"
 )
 
 var db *pgxpool.Pool
 
+// dbtx is what *pgxpool.Pool and pgx.Tx have in common, so helpers can run inside or outside a transaction
+type dbtx interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 func connectToDb() error {
 	//if err := godotenv.Load(); err != nil {
 	//	log.Fatal("Error loading .env file")
 	//}
 	// docker compose handles it
 
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		log.Fatal("DATABASE_URL environment variable not set")
+	poolConfig, err := pgxpool.ParseConfig(config.DatabaseURL)
+	if err != nil {
+		return err
 	}
+	if config.DBMaxConns > 0 {
+		poolConfig.MaxConns = config.DBMaxConns
+	}
+	poolConfig.MinConns = config.DBMinConns
+	poolConfig.MaxConnLifetime = config.DBMaxConnLifetime
+	poolConfig.MaxConnIdleTime = config.DBMaxConnIdleTime
+	poolConfig.ConnConfig.ConnectTimeout = config.DBConnectTimeout
 
-	var err error
-	db, err = pgxpool.New(context.Background(), dsn)
+	// NewWithConfig doesn't connect yet, the ping is what finds out whether Postgres is up
+	db, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	if err := waitForDb(); err != nil {
+		db.Close()
+		return err
 	}
 	return nil
 }
+
+// waitForDb pings until Postgres answers or DB_STARTUP_TIMEOUT runs out. In compose the
+// backend usually starts before the database accepts connections, so the first few fail.
+func waitForDb() error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DBStartupTimeout)
+	defer cancel()
+
+	backoff := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := db.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		log.Printf("database not ready (attempt %d), retrying in %s: %v", attempt, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not reachable after %s: %w", config.DBStartupTimeout, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 5*time.Second)
+	}
+}
+
+// isSerializationFailure reports whether a REPEATABLE READ transaction lost a race with a
+// concurrent write to a row it depends on
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001" // serialization_failure
+}
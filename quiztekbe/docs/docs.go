@@ -15,9 +15,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/question/{id}": {
-            "get": {
-                "description": "Retrieve the details of a question by its ID.",
+        "/auth/login": {
+            "post": {
+                "description": "Exchange an email and password for a bearer token to send in the Authorization header.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,69 +25,67 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "question"
+                    "auth"
                 ],
-                "summary": "Get a single question",
+                "summary": "Log in",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Question ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Email and password",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.User_Credentials"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Token, expiry and user",
                         "schema": {
-                            "$ref": "#/definitions/main.Question"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid question ID",
+                        "description": "Cannot parse JSON",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     },
-                    "404": {
-                        "description": "Question not found",
+                    "401": {
+                        "description": "Invalid email or password",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a question by its ID, then update the positions of subsequent questions in the same quiz.",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Invalidate the bearer token used for this request.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "question"
-                ],
-                "summary": "Delete a question",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Question ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "auth"
                 ],
+                "summary": "Log out",
                 "responses": {
                     "200": {
-                        "description": "Status message indicating deletion",
+                        "description": "Logged out",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -95,37 +93,55 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Invalid question ID",
+                    "401": {
+                        "description": "Login required",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     },
-                    "404": {
-                        "description": "Question not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return the user that owns the bearer token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Get the current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.User"
                         }
                     },
-                    "500": {
-                        "description": "Error during deletion or position update",
+                    "401": {
+                        "description": "Login required",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Update specific fields of an existing question by its ID. The quiz_id remains unchanged.",
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Create an account with an email and password, and log it in straight away.",
                 "consumes": [
                     "application/json"
                 ],
@@ -133,112 +149,86 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "question"
+                    "auth"
                 ],
-                "summary": "Update a question",
+                "summary": "Register a new user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Question ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Fields to update for the question",
+                        "description": "Email and password",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.Question_Update"
+                            "$ref": "#/definitions/main.User_Credentials"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Success status message",
+                    "201": {
+                        "description": "Token, expiry and user",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid question ID or JSON payload",
+                        "description": "Invalid email or password",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already registered",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     },
                     "500": {
-                        "description": "Failed to update question",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     }
                 }
             }
         },
-        "/quiz": {
+        "/category": {
             "get": {
-                "description": "Get all quizzes from the database, optionally filtering by title, category, or date.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List every category by name, with how many quizzes are in it directly and including its subcategories.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "quiz"
-                ],
-                "summary": "Get all quizzes",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Quiz title to search for",
-                        "name": "title",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Quiz category to search for",
-                        "name": "category",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Quiz creation date to search for",
-                        "name": "date",
-                        "in": "query"
-                    }
+                    "category"
                 ],
+                "summary": "List categories",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/main.Quiz"
+                                "$ref": "#/definitions/main.Category"
                             }
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/category/create": {
             "post": {
-                "description": "Create a new quiz with the provided title and category.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a category, optionally under a parent. The slug is derived from the name unless given. The caller is recorded as its creator, who can change or delete it later.",
                 "consumes": [
                     "application/json"
                 ],
@@ -246,69 +236,80 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "quiz"
+                    "category"
                 ],
-                "summary": "Create a new quiz",
+                "summary": "Create a category",
                 "parameters": [
                     {
-                        "description": "Quiz to create",
-                        "name": "quiz",
+                        "description": "Category to create",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.Quiz_Post"
+                            "$ref": "#/definitions/main.Category_Post"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Quiz added message",
+                        "description": "New category id and slug",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Invalid JSON payload",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Login required",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug already taken",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid name, slug or parent",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     }
                 }
             }
         },
-        "/quiz/question/{id}": {
-            "get": {
-                "description": "Retrieve an ordered list of question IDs for a specific quiz.",
-                "consumes": [
-                    "application/json"
+        "/category/delete/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Delete a category that has no subcategories. Its quizzes move up to the parent category, or become uncategorised at the top level. Only the category's creator or an admin can delete it.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "quiz",
-                    "question"
+                    "category"
                 ],
-                "summary": "Get question IDs for a quiz",
+                "summary": "Delete a category",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Quiz ID",
+                        "description": "Category ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -316,38 +317,61 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Category deleted",
                         "schema": {
-                            "type": "array",
-                            "items": {
+                            "type": "object",
+                            "additionalProperties": {
                                 "type": "string"
                             }
                         }
                     },
                     "400": {
-                        "description": "Invalid quiz id",
+                        "description": "Invalid category ID",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Login required",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the category's creator or an admin",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Category still has subcategories",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     },
                     "500": {
-                        "description": "Error fetching questions",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     }
                 }
             }
         },
-        "/quiz/{id}": {
-            "get": {
-                "description": "Retrieve the details of a quiz by its ID.",
+        "/category/edit/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rename a category, change its slug or move it under another parent. Only the fields that are sent change, a parent_id of \"\" moves it to the top level. Quizzes in it pick up the new name. Only the category's creator or an admin can change it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -355,36 +379,148 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "quiz"
+                    "category"
                 ],
-                "summary": "Get a single quiz",
+                "summary": "Update a category",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Quiz ID",
+                        "description": "Category ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Category_Patch"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.Quiz_Detail"
+                            "$ref": "#/definitions/main.Category"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid category ID or JSON payload",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Login required",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the category's creator or an admin",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug already taken",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid name, slug or parent, or the parent is inside this category",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete an existing quiz by its ID.",
+            }
+        },
+        "/category/{id}": {
+            "get": {
+                "description": "Get one category by its ID or slug.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "category"
+                ],
+                "summary": "Get a category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Category"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Answers as long as the process can serve requests, without touching the database.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Health_Status"
+                        }
+                    }
+                }
+            }
+        },
+        "/question/batch/{id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a list of complete questions in one transaction, in order, at the end of the quiz or from a 1-based position. Either every question is valid and they're all created, or nothing is.",
                 "consumes": [
                     "application/json"
                 ],
@@ -392,9 +528,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "quiz"
+                    "quiz",
+                    "question"
                 ],
-                "summary": "Delete a quiz",
+                "summary": "Add several questions to a quiz",
                 "parameters": [
                     {
                         "type": "string",
@@ -402,40 +539,78 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Position to insert the first question at, defaults to the end",
+                        "name": "position",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Questions to create",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Question_Update"
+                            }
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Quiz deleted message",
+                    "201": {
+                        "description": "question_id and position of each new question",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
                             }
                         }
                     },
                     "400": {
-                        "description": "Bad request or invalid quiz ID",
+                        "description": "Invalid quiz ID or JSON payload",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the creator or a collaborator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Position out of range or questions fail validation, fields are prefixed with the question's index",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.API_Error"
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Update the title and category of an existing quiz.",
+            }
+        },
+        "/question/create/{id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a new question to a quiz, at the end of the question list unless a 1-based position is given, in which case the questions from there on move down one. Without a body the question starts as a blank 'tf' question, with one it has to be a valid question.",
                 "consumes": [
                     "application/json"
                 ],
@@ -443,9 +618,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "quiz"
+                    "quiz",
+                    "question"
                 ],
-                "summary": "Update a quiz",
+                "summary": "Add a new question to a quiz",
                 "parameters": [
                     {
                         "type": "string",
@@ -455,24 +631,91 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Quiz update data",
-                        "name": "quiz",
+                        "type": "integer",
+                        "description": "Position to insert at, defaults to the end",
+                        "name": "position",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Question content",
+                        "name": "body",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.Quiz_Update"
+                            "$ref": "#/definitions/main.Question_Update"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "New question details including question_id and position",
                         "schema": {
-                            "$ref": "#/definitions/main.Quiz_Update"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Bad request or invalid quiz ID",
+                        "description": "Invalid quiz ID or JSON payload",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the creator or a collaborator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Position out of range or question fails validation",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Error getting question count or inserting new question",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/question/delete/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a question by its ID, then update the positions of subsequent questions in the same quiz.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "question"
+                ],
+                "summary": "Delete a question",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Question ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status message indicating deletion",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -480,72 +723,2146 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "400": {
+                        "description": "Invalid question ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the creator or a collaborator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Question not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Error during deletion or position update",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/question/edit/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the content of an existing question by its ID. The quiz_id and position remain unchanged, and the content has to be valid for its type.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "question"
+                ],
+                "summary": "Update a question",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Question ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update for the question",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Question_Update"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success status message",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Invalid question ID or JSON payload",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the creator or a collaborator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Question not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Question fails validation, with the problems listed under fields",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to update question",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/question/import/{id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Parse questions from a CSV (header row with type, message, choices, answer and optional points, penalty, scoring_mode, lists separated by \"|\") or Moodle GIFT body and append them after the quiz's existing questions. With dry_run nothing is saved and the parsed questions come back with any errors. Otherwise one bad question means none are added.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz",
+                    "question"
+                ],
+                "summary": "Import questions from CSV or GIFT",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv or gift",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only parse and validate",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Questions in the given format",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dry run: parsed questions and line numbered errors",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "201": {
+                        "description": "question_id and position of each new question",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID or format",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the creator or a collaborator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Line numbered errors, nothing was imported",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/question/{id}": {
+            "get": {
+                "description": "Retrieve the details of a question by its ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "question"
+                ],
+                "summary": "Get a single question",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Question ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Question"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid question ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Question not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz": {
+            "get": {
+                "description": "List quizzes a page at a time. Filters combine, sort is created_at (newest first), title (A to Z) or popularity (most attempts first), and next_cursor fetches the following page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Get all quizzes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz title to search for",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category ID or slug, includes its subcategories",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Quiz creation date to search for",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Creator email",
+                        "name": "creator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated tags",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "all (default) to need every tag, any for at least one",
+                        "name": "tag_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created_at, title or popularity",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc or desc, defaults depend on sort",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 1 to 100, default 20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Quiz_Page"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid tag mode, sort, order, limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/collaborator/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the users who can edit a quiz besides its creator. Only the creator can see this.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "List quiz collaborators",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Quiz_Collaborator"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the quiz creator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Let another registered user edit the quiz. Only the creator can do this.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Add a quiz collaborator",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Collaborator email",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Collaborator_Post"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Collaborator added",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID or email",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the quiz creator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz or user not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/collaborator/{id}/{email}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke a collaborator's edit access. Only the creator can do this.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Remove a quiz collaborator",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collaborator email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Collaborator removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the quiz creator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz or collaborator not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/create": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new quiz with the provided title, category and optional time limit in seconds. The category is required: a category_id, or the id or slug of an existing category. Categories are created through POST /category.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Create a new quiz",
+                "parameters": [
+                    {
+                        "description": "Quiz to create",
+                        "name": "quiz",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Quiz_Post"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Quiz added message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Login required",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid time limit, or a missing or unknown category",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/delete/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete an existing quiz by its ID. Only the quiz creator can do this.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Delete a quiz",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quiz deleted message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or invalid quiz ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the quiz creator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/edit/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update the title, category and time limit of an existing quiz. Only the fields that are sent change: a category of \"\" makes the quiz uncategorised and a time_limit of 0 makes it untimed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Update a quiz",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "quiz",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Quiz_Update"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Quiz_Update"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or invalid quiz ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the creator or a collaborator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Empty title, invalid time limit or unknown category",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/export/qti/{id}": {
+            "get": {
+                "description": "Download a quiz as an IMS QTI 2.1 content package: a zip of one assessmentItem per question plus imsmanifest.xml. 'tf', 'mc' and 'ms' become choiceInteractions, 'fib' a textEntryInteraction per blank. Fib questions with regex answers can't be expressed in QTI, they're left out and listed in the X-QTI-Skipped header.",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Export a quiz as QTI 2.1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "X-QTI-Skipped": {
+                                "type": "string",
+                                "description": "Comma separated ids of the questions left out, only sent when there are any"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/export/{id}": {
+            "get": {
+                "description": "Download a quiz and its questions, in order, as a versioned JSON bundle that POST /quiz/import accepts.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Export a quiz",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Quiz_Bundle"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/fork/{id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Copy a quiz, its tags and all of its questions, in the same order and with the same choices and answers, into a new quiz owned by the caller. The copy records the quiz it came from. Collaborators, attempts and answers aren't copied.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Fork a quiz",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID to fork",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "New quiz id and the source quiz id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Login required",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Quiz was deleted during the copy",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new quiz owned by the caller from a JSON bundle made by GET /quiz/export. The quiz and questions get fresh IDs. If any question is invalid nothing is created and every problem is reported.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Import a quiz",
+                "parameters": [
+                    {
+                        "description": "Exported quiz",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Quiz_Bundle"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "New quiz id and question count",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON payload",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Login required",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unsupported bundle or invalid questions",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/import/qti": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new quiz owned by the caller from an IMS QTI 2.1 content package. Items using interactions other than choice and text entry are skipped and listed in the response. If any supported item turns out invalid nothing is created.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Import a QTI 2.1 package",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "QTI zip package",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Quiz title, defaults to the file name",
+                        "name": "title",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Quiz category",
+                        "name": "category",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "New quiz id, question count and skipped items",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Missing or unreadable package",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Login required",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "422": {
+                        "description": "No supported items, or items fail validation",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/owner/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Hand the quiz over to another registered user, who becomes its creator. Only the owner or an admin can do this, and admins use it to give quizzes without a creator an owner again. A new owner who was a collaborator stops being one, the old owner keeps no access.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Change a quiz's owner",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New owner email",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Owner_Put"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Owner changed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID or email",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the quiz creator or an admin",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz or user not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/question/{id}": {
+            "get": {
+                "description": "Retrieve an ordered list of question IDs for a specific quiz.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz",
+                    "question"
+                ],
+                "summary": "Get question IDs for a quiz",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz id",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Error fetching questions",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/reorder/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rewrite question positions in one transaction, either from the quiz's full new order of question IDs or by moving the question at position from to position to (both 1-based).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz",
+                    "question"
+                ],
+                "summary": "Reorder a quiz's questions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New order, or a single move",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Question_Reorder"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Question IDs in their new order",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID or JSON payload",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the creator or a collaborator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Order doesn't match the quiz's questions",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/search": {
+            "get": {
+                "description": "Full-text search over quiz titles and categories and their questions' messages and choices, best matches first. q takes web search syntax: quoted phrases, OR, and -word to exclude.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Search quizzes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search terms",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 1 to 100, default 20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Results to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Quiz_Search_Result"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Missing search terms or invalid paging",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/tag/{id}": {
+            "get": {
+                "description": "Get the tags on a quiz in alphabetical order.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz",
+                    "tag"
+                ],
+                "summary": "List a quiz's tags",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add tags to a quiz, creating any that don't exist yet. Tags are stored lowercase with runs of other characters turned into dashes, tags the quiz already has are ignored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz",
+                    "tag"
+                ],
+                "summary": "Tag a quiz",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tags to add",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Quiz_Tags_Post"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The quiz's tags afterwards",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID or JSON payload",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the creator or a collaborator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid tags",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/tag/{id}/{tag}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove one tag from a quiz.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz",
+                    "tag"
+                ],
+                "summary": "Untag a quiz",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tag",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tag removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID or tag",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Not the creator or a collaborator",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found or doesn't have the tag",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/{id}": {
+            "get": {
+                "description": "Retrieve the details of a quiz by its ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiz"
+                ],
+                "summary": "Get a single quiz",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Quiz_Detail"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz id",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Pings the database and checks every embedded migration has been applied. Answers 503 otherwise, the reason only goes to the server log.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Health_Status"
+                        }
+                    },
+                    "503": {
+                        "description": "Not ready",
+                        "schema": {
+                            "$ref": "#/definitions/main.Health_Status"
+                        }
+                    }
+                }
+            }
+        },
+        "/submission/answer/{id}": {
+            "put": {
+                "description": "Save or replace the answer to one question of an attempt that is still in progress and before its deadline.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "submission"
+                ],
+                "summary": "Save an answer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Attempt ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Answer, question_id says which question",
+                        "name": "answer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Submission_answer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Answer saved",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid attempt ID, JSON payload, or a question from another quiz",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Attempt not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Attempt already submitted or its deadline has passed",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/submission/attempt/complete/{attemptid}": {
+            "put": {
+                "description": "Close an attempt and grade it once, the score is stored so reads never recompute it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "submission"
+                ],
+                "summary": "Submit an attempt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Attempt ID",
+                        "name": "attemptid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status, score and total",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid attempt ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Attempt not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Attempt already submitted",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/submission/attempt/{id}": {
+            "post": {
+                "description": "Start an attempt at a quiz. A timed quiz fixes the deadline now, so later changes to the time limit don't move it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "submission"
+                ],
+                "summary": "Start an attempt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "attempt_id, started_at and deadline (null when untimed)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/submission/latest/{id}": {
+            "get": {
+                "description": "The five most recently graded attempts at a quiz, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "submission"
+                ],
+                "summary": "Get the latest results of a quiz",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Submission_Result"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid quiz ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/submission/result/{attemptid}": {
+            "get": {
+                "description": "Every question of the quiz in position order with the submitted answer, the correct answer, whether it was right and the points earned, all as they were when the attempt was graded.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "submission"
+                ],
+                "summary": "Get the graded breakdown of an attempt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Attempt ID",
+                        "name": "attemptid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Attempt_Result"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid attempt ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Attempt not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Attempt not graded yet",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/submission/{attemptid}/{questionid}": {
+            "get": {
+                "description": "The answer saved for one question of an attempt.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "submission"
+                ],
+                "summary": "Get a saved answer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Attempt ID",
+                        "name": "attemptid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Question ID",
+                        "name": "questionid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Submission_answer"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid attempt or question ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Question not answered in this attempt",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/tag": {
+            "get": {
+                "description": "The most used tags with how many quizzes have each, most used first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Tag cloud",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "How many tags, 1 to 100, default 50",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Tag_Count"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/main.API_Error"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "main.API_Error": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "details": {
+                    "description": "endpoint specific extras, e.g. the lines a text import choked on"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Field_Error"
+                    }
+                }
+            }
+        },
+        "main.Attempt_Result": {
+            "type": "object",
+            "properties": {
+                "attempt_id": {
+                    "type": "string"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Question_Result"
+                    }
+                },
+                "quiz_id": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.Category": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "nil for categories only admins can change",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "quiz_count": {
+                    "description": "quizzes directly in this category",
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "total_quiz_count": {
+                    "description": "including every subcategory",
+                    "type": "integer"
+                }
+            }
+        },
+        "main.Category_Patch": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "description": "a category id, or \"\" to move it to the top level",
+                    "type": "string"
+                },
+                "slug": {
+                    "description": "\"\" derives it from the name again",
+                    "type": "string"
+                }
+            }
+        },
+        "main.Category_Post": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "slug": {
+                    "description": "derived from name when empty",
+                    "type": "string"
+                }
+            }
+        },
+        "main.Collaborator_Post": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Fib_Options": {
+            "type": "object",
+            "properties": {
+                "alternatives": {
+                    "description": "extra accepted answers, indexed like correct_answers",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
                     }
+                },
+                "case_insensitive": {
+                    "type": "boolean"
+                },
+                "numeric_tolerance": {
+                    "description": "numbers within this distance of the answer count",
+                    "type": "number"
+                },
+                "regex": {
+                    "description": "accepted answers are regular expressions matched against the whole blank",
+                    "type": "boolean"
+                },
+                "trim_whitespace": {
+                    "type": "boolean"
                 }
             }
         },
-        "/quiz/{id}/question": {
-            "post": {
-                "description": "Add a new question at the end of the question list for a specified quiz. The position is set as the current count of questions for the quiz plus one.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "quiz",
-                    "question"
-                ],
-                "summary": "Add a new question to a quiz",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Quiz ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "New question details including question_id and position",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid quiz ID",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Error getting question count or inserting new question",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
+        "main.Field_Error": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
+        },
+        "main.Health_Status": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "description": "\"ok\" or \"unavailable\"",
+                    "type": "string"
+                }
+            }
+        },
+        "main.Owner_Put": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
         "main.Question": {
             "type": "object",
             "properties": {
@@ -567,9 +2884,26 @@ const docTemplate = `{
                 "correct_choice": {
                     "type": "integer"
                 },
+                "correct_choices": {
+                    "description": "'ms' only",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "fib_options": {
+                    "$ref": "#/definitions/main.Fib_Options"
+                },
                 "message": {
                     "type": "string"
                 },
+                "penalty": {
+                    "description": "'tf' and 'mc' only, taken off for a wrong answer",
+                    "type": "number"
+                },
+                "points": {
+                    "type": "number"
+                },
                 "position": {
                     "type": "integer"
                 },
@@ -579,12 +2913,65 @@ const docTemplate = `{
                 "quiz_id": {
                     "type": "string"
                 },
+                "scoring_mode": {
+                    "description": "'ms' only: 'all_or_nothing' (default) or 'partial'",
+                    "type": "string"
+                },
                 "type": {
-                    "description": "'tf', 'mc', 'fib'",
+                    "description": "'tf', 'mc', 'ms', 'fib'",
                     "type": "string"
                 }
             }
         },
+        "main.Question_Reorder": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "integer"
+                },
+                "question_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.Question_Result": {
+            "type": "object",
+            "properties": {
+                "is_correct": {
+                    "type": "boolean"
+                },
+                "max_points": {
+                    "description": "what the question was worth when graded",
+                    "type": "number"
+                },
+                "points": {
+                    "description": "earned on this question, negative when penalised",
+                    "type": "number"
+                },
+                "question": {
+                    "description": "as graded, includes the correct answer",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/main.Question"
+                        }
+                    ]
+                },
+                "submitted": {
+                    "description": "nil when left unanswered",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/main.Submission_answer"
+                        }
+                    ]
+                }
+            }
+        },
         "main.Question_Update": {
             "type": "object",
             "properties": {
@@ -606,9 +2993,29 @@ const docTemplate = `{
                 "correct_choice": {
                     "type": "integer"
                 },
+                "correct_choices": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "fib_options": {
+                    "$ref": "#/definitions/main.Fib_Options"
+                },
                 "message": {
                     "type": "string"
                 },
+                "penalty": {
+                    "description": "nil keeps the current value",
+                    "type": "number"
+                },
+                "points": {
+                    "description": "nil keeps the current value",
+                    "type": "number"
+                },
+                "scoring_mode": {
+                    "type": "string"
+                },
                 "type": {
                     "type": "string"
                 }
@@ -618,6 +3025,10 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "category": {
+                    "description": "name of the category",
+                    "type": "string"
+                },
+                "category_id": {
                     "type": "string"
                 },
                 "created_at": {
@@ -629,17 +3040,60 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "time_limit": {
+                    "description": "seconds, nil means untimed",
+                    "type": "integer"
+                },
                 "title": {
                     "type": "string"
                 }
             }
         },
+        "main.Quiz_Bundle": {
+            "type": "object",
+            "properties": {
+                "exported_at": {
+                    "type": "string"
+                },
+                "format": {
+                    "description": "always \"quiztek.quiz\"",
+                    "type": "string"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Question"
+                    }
+                },
+                "quiz": {
+                    "$ref": "#/definitions/main.Quiz"
+                },
+                "version": {
+                    "description": "bumped on incompatible changes",
+                    "type": "integer"
+                }
+            }
+        },
+        "main.Quiz_Collaborator": {
+            "type": "object",
+            "properties": {
+                "added_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
         "main.Quiz_Detail": {
             "type": "object",
             "properties": {
                 "category": {
                     "type": "string"
                 },
+                "category_id": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
@@ -649,32 +3103,201 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "source_quiz_id": {
+                    "description": "set on forks",
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "time_limit": {
+                    "description": "seconds, nil means untimed",
+                    "type": "integer"
+                },
                 "title": {
                     "type": "string"
                 }
             }
         },
+        "main.Quiz_Page": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "quizzes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Quiz"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
         "main.Quiz_Post": {
             "type": "object",
             "properties": {
                 "category": {
+                    "description": "slug or id of an existing category, used when category_id is empty",
+                    "type": "string"
+                },
+                "category_id": {
+                    "description": "takes precedence over category",
                     "type": "string"
                 },
+                "time_limit": {
+                    "type": "integer"
+                },
                 "title": {
                     "type": "string"
                 }
             }
         },
+        "main.Quiz_Search_Result": {
+            "type": "object",
+            "properties": {
+                "quiz": {
+                    "$ref": "#/definitions/main.Quiz"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "snippet": {
+                    "description": "best matching question, nil when only the title or category matched",
+                    "type": "string"
+                },
+                "title_highlight": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Quiz_Tags_Post": {
+            "type": "object",
+            "properties": {
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "main.Quiz_Update": {
             "type": "object",
             "properties": {
                 "category": {
+                    "description": "slug or id of an existing category, \"\" makes the quiz uncategorised",
                     "type": "string"
                 },
+                "category_id": {
+                    "description": "takes precedence over category",
+                    "type": "string"
+                },
+                "time_limit": {
+                    "description": "0 removes the limit",
+                    "type": "integer"
+                },
                 "title": {
                     "type": "string"
                 }
             }
+        },
+        "main.Submission_Result": {
+            "type": "object",
+            "properties": {
+                "attempt_id": {
+                    "type": "string"
+                },
+                "completed_at": {
+                    "description": "formatted as \"HH:MM DD Month YYYY\"",
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.Submission_answer": {
+            "type": "object",
+            "properties": {
+                "answer_tf": {
+                    "type": "boolean"
+                },
+                "attempt_id": {
+                    "type": "string"
+                },
+                "correct_answers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "correct_choice": {
+                    "type": "integer"
+                },
+                "question_id": {
+                    "type": "string"
+                },
+                "selected_choices": {
+                    "description": "'ms' only",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "main.Tag_Count": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "is_admin": {
+                    "description": "listed in ADMIN_EMAILS",
+                    "type": "boolean"
+                }
+            }
+        },
+        "main.User_Credentials": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Grader scores one submitted answer against the question it answers. The result is the
+// credit earned, from 0 (wrong) to 1 (fully right).
+type Grader interface {
+	Grade(question Question, answer Submission_answer) float64
+}
+
+// graders maps questions.type to the Grader for it.
+var graders = map[string]Grader{
+	"tf":  tfGrader{},
+	"mc":  mcGrader{},
+	"ms":  msGrader{},
+	"fib": fibGrader{},
+}
+
+const (
+	scoringAllOrNothing = "all_or_nothing"
+	scoringPartial      = "partial"
+)
+
+// negativeMarking is the types where a wrong answer costs the question's penalty. Those
+// are the ones a blind guess can get right, 'ms' already punishes wrong picks in partial mode.
+var negativeMarking = map[string]bool{"tf": true, "mc": true}
+
+// questionGrade is the outcome for one question: the grader's credit and the points it's worth.
+type questionGrade struct {
+	Credit float64
+	Points float64
+}
+
+type tfGrader struct{}
+
+func (tfGrader) Grade(question Question, answer Submission_answer) float64 {
+	if question.Answer_tf == nil || answer.Answer_tf == nil {
+		return 0
+	}
+	return boolCredit(*answer.Answer_tf == *question.Answer_tf)
+}
+
+type mcGrader struct{}
+
+func (mcGrader) Grade(question Question, answer Submission_answer) float64 {
+	if question.Correct_choice == nil || answer.Correct_choice == nil {
+		return 0
+	}
+	return boolCredit(*answer.Correct_choice == *question.Correct_choice)
+}
+
+// msGrader compares the selected choices with correct_choices as sets. In partial mode
+// each correct pick earns a share of the credit and each wrong pick takes one away,
+// never going below zero.
+type msGrader struct{}
+
+func (msGrader) Grade(question Question, answer Submission_answer) float64 {
+	correct := choiceSet(question.Correct_choices)
+	selected := choiceSet(answer.Selected_choices)
+	if len(correct) == 0 || len(selected) == 0 {
+		return 0
+	}
+
+	hits, misses := 0, 0
+	for choice := range selected {
+		if correct[choice] {
+			hits++
+		} else {
+			misses++
+		}
+	}
+
+	if question.Scoring_mode == scoringPartial {
+		return math.Max(0, float64(hits-misses)/float64(len(correct)))
+	}
+	return boolCredit(hits == len(correct) && misses == 0)
+}
+
+func choiceSet(choices []int) map[int]bool {
+	set := make(map[int]bool, len(choices))
+	for _, choice := range choices {
+		set[choice] = true
+	}
+	return set
+}
+
+// fibGrader wants every blank to match its correct answer, exactly unless the
+// question has Fib_options.
+type fibGrader struct{}
+
+func (fibGrader) Grade(question Question, answer Submission_answer) float64 {
+	if len(question.Correct_answers) == 0 || len(answer.Correct_answers) != len(question.Correct_answers) {
+		return 0
+	}
+	opts := question.Fib_options
+	if opts == nil {
+		opts = &Fib_Options{}
+	}
+	for i := range question.Correct_answers {
+		if !opts.matches(answer.Correct_answers[i], opts.accepted(question.Correct_answers, i)) {
+			return 0
+		}
+	}
+	return 1
+}
+
+// accepted lists every answer that counts for blank i.
+func (o *Fib_Options) accepted(correctAnswers []string, i int) []string {
+	accepted := []string{correctAnswers[i]}
+	if i < len(o.Alternatives) {
+		accepted = append(accepted, o.Alternatives[i]...)
+	}
+	return accepted
+}
+
+// matches reports whether the submitted blank matches any accepted answer.
+func (o *Fib_Options) matches(submitted string, accepted []string) bool {
+	if o.Trim_whitespace {
+		submitted = strings.TrimSpace(submitted)
+	}
+	for _, want := range accepted {
+		if o.Trim_whitespace {
+			want = strings.TrimSpace(want)
+		}
+
+		if o.Regex {
+			re, err := o.compile(want)
+			if err != nil {
+				log.Printf("bad fib regex %q: %v", want, err)
+				continue
+			}
+			if re.MatchString(submitted) {
+				return true
+			}
+			continue
+		}
+
+		if o.Numeric_tolerance != nil {
+			got, errGot := strconv.ParseFloat(submitted, 64)
+			exp, errExp := strconv.ParseFloat(want, 64)
+			if errGot == nil && errExp == nil {
+				if math.Abs(got-exp) <= *o.Numeric_tolerance {
+					return true
+				}
+				continue
+			}
+		}
+
+		if o.Case_insensitive && strings.EqualFold(submitted, want) {
+			return true
+		}
+		if submitted == want {
+			return true
+		}
+	}
+	return false
+}
+
+// compile anchors the pattern so it has to match the whole blank, not just part of it.
+func (o *Fib_Options) compile(pattern string) (*regexp.Regexp, error) {
+	flags := ""
+	if o.Case_insensitive {
+		flags = "(?i)"
+	}
+	return regexp.Compile(flags + "^(?:" + pattern + ")$")
+}
+
+func boolCredit(correct bool) float64 {
+	if correct {
+		return 1
+	}
+	return 0
+}
+
+// gradeQuestion runs the grader for the question's type. Unanswered questions and
+// unknown types get no credit.
+func gradeQuestion(question Question, answer *Submission_answer) float64 {
+	if answer == nil {
+		return 0
+	}
+	grader, ok := graders[question.Type]
+	if !ok {
+		log.Printf("no grader for question type %q (question %s)", question.Type, question.Question_id)
+		return 0
+	}
+	return grader.Grade(question, *answer)
+}
+
+// pointsFor turns a grader's credit into points: a share of the question's points, or
+// minus the penalty for a wrong answer where negative marking applies.
+func pointsFor(question Question, answer *Submission_answer, credit float64) float64 {
+	if answer != nil && credit == 0 && negativeMarking[question.Type] {
+		return -question.Penalty
+	}
+	return credit * question.Points
+}
+
+// scoreAnswers grades each of a quiz's questions given the answers keyed by question id.
+// grades lines up with questions. total is the sum of every question's points, and score
+// never drops below zero however many penalties there were.
+func scoreAnswers(questions []Question, answers map[uuid.UUID]Submission_answer) (grades []questionGrade, score float64, total float64) {
+	grades = make([]questionGrade, len(questions))
+	for i, question := range questions {
+		var answer *Submission_answer
+		if a, ok := answers[question.Question_id]; ok {
+			answer = &a
+		}
+		credit := gradeQuestion(question, answer)
+		grades[i] = questionGrade{Credit: credit, Points: pointsFor(question, answer, credit)}
+		score += grades[i].Points
+		total += question.Points
+	}
+	return grades, math.Max(0, score), total
+}
+
+// loadAttempt fetches the attempt's quiz questions in position order and its answers
+// keyed by question id.
+func loadAttempt(ctx context.Context, q dbtx, attemptID uuid.UUID) ([]Question, map[uuid.UUID]Submission_answer, error) {
+	queryStr := `
+		SELECT ` + questionColumns + `
+		FROM questions
+		WHERE quiz_id = (SELECT quiz_id FROM submission_attempts WHERE attempt_id = $1)
+		ORDER BY position
+	`
+	rows, err := q.Query(ctx, queryStr, attemptID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var questions []Question
+	for rows.Next() {
+		var question Question
+		if err := scanQuestion(rows, &question); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		questions = append(questions, question)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	queryStr = `
+		SELECT question_id, answer_tf, correct_choice, correct_answers, selected_choices
+		FROM submission_answers
+		WHERE attempt_id = $1
+	`
+	rows, err = q.Query(ctx, queryStr, attemptID)
+	if err != nil {
+		return nil, nil, err
+	}
+	answers := make(map[uuid.UUID]Submission_answer)
+	for rows.Next() {
+		answer := Submission_answer{Attempt_id: attemptID}
+		if err := rows.Scan(&answer.Question_id, &answer.Answer_tf, &answer.Correct_choice, &answer.Correct_answers, &answer.Selected_choices); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		answers[answer.Question_id] = answer
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return questions, answers, nil
+}
+
+// gradeAttempt scores a submitted attempt against its quiz's current questions, stores a
+// snapshot of every question with its grade in attempt_grades and the totals on
+// submission_attempts, and moves it to graded.
+func gradeAttempt(ctx context.Context, q dbtx, attemptID uuid.UUID) (float64, float64, error) {
+	questions, answers, err := loadAttempt(ctx, q, attemptID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	grades, score, total := scoreAnswers(questions, answers)
+
+	queryStr := "UPDATE submission_attempts SET score = $2, total = $3, status = $4 WHERE attempt_id = $1 AND status = $5"
+	tag, err := q.Exec(ctx, queryStr, attemptID, score, total, attemptGraded, attemptSubmitted)
+	if err != nil {
+		return 0, 0, err
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, 0, errAttemptState
+	}
+
+	// the result page reads these back instead of the quiz, so it always agrees with score
+	// even after the questions are edited
+	positions := make([]int, len(questions))
+	questionsJSON := make([]string, len(questions))
+	answersJSON := make([]*string, len(questions))
+	credits := make([]float64, len(questions))
+	points := make([]float64, len(questions))
+	maxPoints := make([]float64, len(questions))
+	for i, question := range questions {
+		data, err := json.Marshal(question)
+		if err != nil {
+			return 0, 0, err
+		}
+		positions[i], questionsJSON[i] = question.Position, string(data)
+		if answer, ok := answers[question.Question_id]; ok {
+			data, err := json.Marshal(answer)
+			if err != nil {
+				return 0, 0, err
+			}
+			answerJSON := string(data)
+			answersJSON[i] = &answerJSON
+		}
+		credits[i], points[i], maxPoints[i] = grades[i].Credit, grades[i].Points, question.Points
+	}
+	queryStr = `
+		INSERT INTO attempt_grades (attempt_id, position, question, answer, credit, points, max_points)
+		SELECT $1, g.position, g.question::jsonb, g.answer::jsonb, g.credit, g.points, g.max_points
+		FROM unnest($2::int[], $3::text[], $4::text[], $5::float8[], $6::float8[], $7::float8[])
+		     AS g(position, question, answer, credit, points, max_points)
+	`
+	if _, err := q.Exec(ctx, queryStr, attemptID, positions, questionsJSON, answersJSON, credits, points, maxPoints); err != nil {
+		return 0, 0, err
+	}
+	return score, total, nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGraders(t *testing.T) {
+	tests := []struct {
+		name     string
+		question Question
+		answer   Submission_answer
+		want     float64
+	}{
+		{"tf right", Question{Type: "tf", Answer_tf: ptr(true)}, Submission_answer{Answer_tf: ptr(true)}, 1},
+		{"tf wrong", Question{Type: "tf", Answer_tf: ptr(true)}, Submission_answer{Answer_tf: ptr(false)}, 0},
+		{"tf blank", Question{Type: "tf", Answer_tf: ptr(false)}, Submission_answer{}, 0},
+
+		{"mc right", Question{Type: "mc", Correct_choice: ptr(2)}, Submission_answer{Correct_choice: ptr(2)}, 1},
+		{"mc wrong", Question{Type: "mc", Correct_choice: ptr(2)}, Submission_answer{Correct_choice: ptr(0)}, 0},
+		{"mc blank", Question{Type: "mc", Correct_choice: ptr(0)}, Submission_answer{}, 0},
+
+		{"ms all right", Question{Type: "ms", Correct_choices: []int{0, 2}}, Submission_answer{Selected_choices: []int{2, 0}}, 1},
+		{"ms missing one", Question{Type: "ms", Correct_choices: []int{0, 2}}, Submission_answer{Selected_choices: []int{0}}, 0},
+		{"ms extra pick", Question{Type: "ms", Correct_choices: []int{0, 2}}, Submission_answer{Selected_choices: []int{0, 1, 2}}, 0},
+		{"ms nothing picked", Question{Type: "ms", Correct_choices: []int{0}}, Submission_answer{}, 0},
+		{"ms partial half", Question{Type: "ms", Correct_choices: []int{0, 2}, Scoring_mode: scoringPartial}, Submission_answer{Selected_choices: []int{0}}, 0.5},
+		{"ms partial wrong cancels right", Question{Type: "ms", Correct_choices: []int{0, 2}, Scoring_mode: scoringPartial}, Submission_answer{Selected_choices: []int{0, 1}}, 0},
+		{"ms partial never negative", Question{Type: "ms", Correct_choices: []int{0}, Scoring_mode: scoringPartial}, Submission_answer{Selected_choices: []int{1, 2, 3}}, 0},
+		{"ms partial duplicates count once", Question{Type: "ms", Correct_choices: []int{0, 1, 2, 3}, Scoring_mode: scoringPartial}, Submission_answer{Selected_choices: []int{0, 0, 1}}, 0.5},
+
+		{"fib exact", Question{Type: "fib", Correct_answers: []string{"Paris"}}, Submission_answer{Correct_answers: []string{"Paris"}}, 1},
+		{"fib case matters by default", Question{Type: "fib", Correct_answers: []string{"Paris"}}, Submission_answer{Correct_answers: []string{"paris"}}, 0},
+		{"fib wrong blank count", Question{Type: "fib", Correct_answers: []string{"a", "b"}}, Submission_answer{Correct_answers: []string{"a"}}, 0},
+		{"fib every blank must match", Question{Type: "fib", Correct_answers: []string{"a", "b"}}, Submission_answer{Correct_answers: []string{"a", "c"}}, 0},
+		{
+			"fib case insensitive and trimmed",
+			Question{Type: "fib", Correct_answers: []string{"Paris"}, Fib_options: &Fib_Options{Case_insensitive: true, Trim_whitespace: true}},
+			Submission_answer{Correct_answers: []string{"  pARIS "}}, 1,
+		},
+		{
+			"fib untrimmed",
+			Question{Type: "fib", Correct_answers: []string{"Paris"}, Fib_options: &Fib_Options{Case_insensitive: true}},
+			Submission_answer{Correct_answers: []string{"Paris "}}, 0,
+		},
+		{
+			"fib alternative for its own blank",
+			Question{Type: "fib", Correct_answers: []string{"colour", "grey"}, Fib_options: &Fib_Options{Alternatives: [][]string{{"color"}, {"gray"}}}},
+			Submission_answer{Correct_answers: []string{"color", "gray"}}, 1,
+		},
+		{
+			"fib alternative for another blank",
+			Question{Type: "fib", Correct_answers: []string{"colour", "grey"}, Fib_options: &Fib_Options{Alternatives: [][]string{{"color"}}}},
+			Submission_answer{Correct_answers: []string{"colour", "color"}}, 0,
+		},
+		{
+			"fib within tolerance",
+			Question{Type: "fib", Correct_answers: []string{"3.14"}, Fib_options: &Fib_Options{Numeric_tolerance: ptr(0.01)}},
+			Submission_answer{Correct_answers: []string{"3.1416"}}, 1,
+		},
+		{
+			"fib outside tolerance",
+			Question{Type: "fib", Correct_answers: []string{"3.14"}, Fib_options: &Fib_Options{Numeric_tolerance: ptr(0.001)}},
+			Submission_answer{Correct_answers: []string{"3.15"}}, 0,
+		},
+		{
+			"fib tolerance falls back to text",
+			Question{Type: "fib", Correct_answers: []string{"pi"}, Fib_options: &Fib_Options{Numeric_tolerance: ptr(0.1)}},
+			Submission_answer{Correct_answers: []string{"pi"}}, 1,
+		},
+		{
+			"fib regex matches whole blank",
+			Question{Type: "fib", Correct_answers: []string{"colou?r"}, Fib_options: &Fib_Options{Regex: true}},
+			Submission_answer{Correct_answers: []string{"color"}}, 1,
+		},
+		{
+			"fib regex is anchored",
+			Question{Type: "fib", Correct_answers: []string{"colou?r"}, Fib_options: &Fib_Options{Regex: true}},
+			Submission_answer{Correct_answers: []string{"watercolor"}}, 0,
+		},
+		{
+			"fib regex case insensitive",
+			Question{Type: "fib", Correct_answers: []string{"colou?r"}, Fib_options: &Fib_Options{Regex: true, Case_insensitive: true}},
+			Submission_answer{Correct_answers: []string{"COLOUR"}}, 1,
+		},
+		{
+			"fib bad regex never matches",
+			Question{Type: "fib", Correct_answers: []string{"("}, Fib_options: &Fib_Options{Regex: true}},
+			Submission_answer{Correct_answers: []string{"("}}, 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer := tt.answer
+			if got := gradeQuestion(tt.question, &answer); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("credit = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGradeQuestionUnansweredOrUnknown(t *testing.T) {
+	if got := gradeQuestion(Question{Type: "tf", Answer_tf: ptr(true)}, nil); got != 0 {
+		t.Errorf("unanswered credit = %v, want 0", got)
+	}
+	if got := gradeQuestion(Question{Type: "essay"}, &Submission_answer{}); got != 0 {
+		t.Errorf("unknown type credit = %v, want 0", got)
+	}
+}
+
+func TestPointsFor(t *testing.T) {
+	answered := &Submission_answer{}
+	tests := []struct {
+		name     string
+		question Question
+		answer   *Submission_answer
+		credit   float64
+		want     float64
+	}{
+		{"full credit", Question{Type: "mc", Points: 4}, answered, 1, 4},
+		{"partial credit", Question{Type: "ms", Points: 4}, answered, 0.5, 2},
+		{"wrong tf costs the penalty", Question{Type: "tf", Points: 2, Penalty: 0.5}, answered, 0, -0.5},
+		{"wrong mc costs the penalty", Question{Type: "mc", Points: 2, Penalty: 1}, answered, 0, -1},
+		{"unanswered costs nothing", Question{Type: "mc", Points: 2, Penalty: 1}, nil, 0, 0},
+		{"no negative marking for ms", Question{Type: "ms", Points: 2, Penalty: 1}, answered, 0, 0},
+		{"no negative marking for fib", Question{Type: "fib", Points: 2, Penalty: 1}, answered, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pointsFor(tt.question, tt.answer, tt.credit); got != tt.want {
+				t.Errorf("points = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreAnswers(t *testing.T) {
+	tf := Question{Question_id: uuid.New(), Type: "tf", Answer_tf: ptr(true), Points: 1, Penalty: 1}
+	mc := Question{Question_id: uuid.New(), Type: "mc", Correct_choice: ptr(1), Points: 2, Penalty: 2}
+	ms := Question{Question_id: uuid.New(), Type: "ms", Correct_choices: []int{0, 1}, Scoring_mode: scoringPartial, Points: 4}
+	questions := []Question{tf, mc, ms}
+
+	t.Run("penalties and partial credit add up", func(t *testing.T) {
+		answers := map[uuid.UUID]Submission_answer{
+			tf.Question_id: {Answer_tf: ptr(true)},
+			mc.Question_id: {Correct_choice: ptr(0)},
+			ms.Question_id: {Selected_choices: []int{0}},
+		}
+		grades, score, total := scoreAnswers(questions, answers)
+		if score != 1 || total != 7 {
+			t.Errorf("score = %v / %v, want 1 / 7", score, total)
+		}
+		wantPoints := []float64{1, -2, 2}
+		for i, g := range grades {
+			if g.Points != wantPoints[i] {
+				t.Errorf("question %d: points %v, want %v", i, g.Points, wantPoints[i])
+			}
+		}
+	})
+
+	t.Run("score is clamped at zero", func(t *testing.T) {
+		answers := map[uuid.UUID]Submission_answer{
+			tf.Question_id: {Answer_tf: ptr(false)},
+			mc.Question_id: {Correct_choice: ptr(0)},
+		}
+		grades, score, total := scoreAnswers(questions, answers)
+		if score != 0 || total != 7 {
+			t.Errorf("score = %v / %v, want 0 / 7", score, total)
+		}
+		if grades[0].Points != -1 || grades[1].Points != -2 || grades[2].Points != 0 {
+			t.Errorf("grades = %+v, want the penalties kept per question", grades)
+		}
+	})
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"context"
-	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
-	"log"
+	"github.com/jackc/pgx/v5"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,49 +16,83 @@ import (
 
 // GetQuizzes godoc
 // @Summary      Get all quizzes
-// @Description  Get all quizzes from the database, optionally filtering by title, category, or date.
+// @Description  List quizzes a page at a time. Filters combine, sort is created_at (newest first), title (A to Z) or popularity (most attempts first), and next_cursor fetches the following page.
 // @Tags         quiz
 // @Accept       json
 // @Produce      json
 // @Param        title     query    string  false  "Quiz title to search for"
-// @Param        category  query    string  false  "Quiz category to search for"
+// @Param        category  query    string  false  "Category ID or slug, includes its subcategories"
 // @Param        date      query    string  false  "Quiz creation date to search for"
-// @Success      200  {array}   Quiz
-// @Failure      500  {object}  map[string]interface{}
+// @Param        creator   query    string  false  "Creator email"
+// @Param        tags      query    string  false  "Comma separated tags"
+// @Param        tag_mode  query    string  false  "all (default) to need every tag, any for at least one"
+// @Param        sort      query    string  false  "created_at, title or popularity"
+// @Param        order     query    string  false  "asc or desc, defaults depend on sort"
+// @Param        limit     query    int     false  "Page size, 1 to 100, default 20"
+// @Param        cursor    query    string  false  "next_cursor from the previous page"
+// @Success      200  {object}  Quiz_Page
+// @Failure      422  {object}  API_Error  "Invalid tag mode, sort, order, limit or cursor"
+// @Failure      500  {object}  API_Error
 // @Router       /quiz [get]
 func GetQuizzes(c *fiber.Ctx) error {
-	queryStr := "SELECT quiz_id, title, category, COALESCE(creator_email, ''), created_at FROM quizzes"
-	var params []interface{}
+	filter, fieldErrs := quizListFilter(c)
+	sort, desc, limit, cursor, pageErrs := quizListPage(c)
+	fieldErrs = append(fieldErrs, pageErrs...)
+	if len(fieldErrs) > 0 {
+		return validationFailed("Invalid quiz listing", fieldErrs)
+	}
+
+	var page Quiz_Page
+	countQuery := "SELECT COUNT(*) FROM quizzes q" + filter.sql()
+	if err := db.QueryRow(context.Background(), countQuery, filter.params...).Scan(&page.Total); err != nil {
+		return internalError("Failed to count quizzes", err)
+	}
 
-	// %something% and ILIKE is sql wildcard
-	if title := c.Query("title"); title != "" {
-		queryStr += " WHERE title ILIKE $1"
-		params = append(params, "%"+title+"%")
-	} else if category := c.Query("category"); category != "" {
-		queryStr += " WHERE category ILIKE $1"
-		params = append(params, "%"+category+"%")
-	} else if date := c.Query("date"); date != "" {
-		queryStr += " WHERE to_char(created_at, 'DD FMMonth YYYY') ILIKE $1"
-		params = append(params, "%"+date+"%")
+	sortBy := quizSorts[sort]
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+	if cursor != nil {
+		key := filter.placeholder(cursor.Key)
+		after := filter.placeholder(cursor.After)
+		filter.where = append(filter.where, fmt.Sprintf("(%s, q.quiz_id) %s (%s::%s, %s::uuid)", sortBy.expr, comparison, key, sortBy.castType, after))
 	}
 
-	rows, err := db.Query(context.Background(), queryStr, params...)
+	// one extra row tells whether there's a next page
+	queryStr := fmt.Sprintf(`
+		SELECT q.quiz_id, q.title, q.category, q.category_id, COALESCE(q.creator_email, ''), q.created_at, q.time_limit_seconds, (%[1]s)::text
+		FROM quizzes q%[2]s
+		ORDER BY %[1]s %[3]s, q.quiz_id %[3]s
+		LIMIT %[4]d
+	`, sortBy.expr, filter.sql(), direction, limit+1)
+
+	rows, err := db.Query(context.Background(), queryStr, filter.params...)
 	if err != nil {
-		log.Println(err)
-		return c.SendStatus(500)
+		return internalError("Failed to fetch quizzes", err)
 	}
 	defer rows.Close() // Query/rows need closing cuz its a "cursor" but QueryRow/row doesnt
 
-	var quizzes []Quiz
+	page.Quizzes = []Quiz{}
+	var lastKey string
 	for rows.Next() {
 		var quiz Quiz
-		if err := rows.Scan(&quiz.Quiz_id, &quiz.Title, &quiz.Category, &quiz.Creator_email, &quiz.Created_at); err != nil {
-			log.Println(err)
-			return c.SendStatus(500)
+		var key string
+		if err := rows.Scan(&quiz.Quiz_id, &quiz.Title, &quiz.Category, &quiz.Category_id, &quiz.Creator_email, &quiz.Created_at, &quiz.Time_limit, &key); err != nil {
+			return internalError("Failed to scan quiz", err)
+		}
+		if len(page.Quizzes) == limit {
+			next := quizCursor{Sort: sort, Desc: desc, Key: lastKey, After: page.Quizzes[limit-1].Quiz_id}.encode()
+			page.Next_cursor = &next
+			break
 		}
-		quizzes = append(quizzes, quiz)
+		page.Quizzes = append(page.Quizzes, quiz)
+		lastKey = key
+	}
+	if err := rows.Err(); err != nil {
+		return internalError("Failed to fetch quizzes", err)
 	}
-	return c.JSON(quizzes)
+	return c.JSON(page)
 }
 
 // GetQuiz godoc
@@ -65,23 +103,33 @@ func GetQuizzes(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id   path      string  true  "Quiz ID"
 // @Success      200  {object}  Quiz_Detail
-// @Failure      500  {object}  map[string]interface{}
+// @Failure      400  {object}  API_Error  "Invalid quiz id"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      500  {object}  API_Error
 // @Router       /quiz/{id} [get]
 func GetQuiz(c *fiber.Ctx) error {
 
 	quizIDStr := c.Params("id")
 	quizID, err := uuid.Parse(quizIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid quiz id"})
+		return badRequest("Invalid quiz id")
 	}
 
-	queryStr := "SELECT quiz_id, title, category, COALESCE(creator_email, ''), created_at FROM quizzes WHERE quiz_id = $1"
+	queryStr := `
+		SELECT quiz_id, title, category, category_id, COALESCE(creator_email, ''), created_at, time_limit_seconds, source_quiz_id,
+		       ARRAY(SELECT t.name FROM quiz_tags qt JOIN tags t ON t.tag_id = qt.tag_id WHERE qt.quiz_id = quizzes.quiz_id ORDER BY t.name)
+		FROM quizzes
+		WHERE quiz_id = $1
+	`
 
 	row := db.QueryRow(context.Background(), queryStr, quizID)
 
 	var quiz_Detail Quiz_Detail
-	if err := row.Scan(&quiz_Detail.Quiz_id, &quiz_Detail.Title, &quiz_Detail.Category, &quiz_Detail.Creator_email, &quiz_Detail.Created_at); err != nil {
-		log.Fatal(err)
+	if err := row.Scan(&quiz_Detail.Quiz_id, &quiz_Detail.Title, &quiz_Detail.Category, &quiz_Detail.Category_id, &quiz_Detail.Creator_email, &quiz_Detail.Created_at, &quiz_Detail.Time_limit, &quiz_Detail.Source_quiz_id, &quiz_Detail.Tags); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Quiz not found")
+		}
+		return internalError("Failed to fetch quiz", err)
 	}
 
 	return c.JSON(quiz_Detail)
@@ -89,91 +137,231 @@ func GetQuiz(c *fiber.Ctx) error {
 
 // PostQuiz godoc
 // @Summary      Create a new quiz
-// @Description  Create a new quiz with the provided title and category.
+// @Description  Create a new quiz with the provided title, category and optional time limit in seconds. The category is required: a category_id, or the id or slug of an existing category. Categories are created through POST /category.
 // @Tags         quiz
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Param        quiz  body      Quiz_Post  true  "Quiz to create"
 // @Success      201   {object}  map[string]string  "Quiz added message"
-// @Failure      400   {object}  map[string]string  "Bad request"
-// @Failure      500   {object}  map[string]string  "Internal server error"
-// @Router       /quiz [post]
+// @Failure      400   {object}  API_Error  "Bad request"
+// @Failure      401   {object}  API_Error  "Login required"
+// @Failure      422   {object}  API_Error  "Invalid time limit, or a missing or unknown category"
+// @Failure      500   {object}  API_Error  "Internal server error"
+// @Router       /quiz/create [post]
 func PostQuiz(c *fiber.Ctx) error {
+	user, _ := currentUser(c)
+
 	var quizPost Quiz_Post
 	if err := c.BodyParser(&quizPost); err != nil {
-		return c.SendStatus(400)
+		return badRequest("Cannot parse JSON")
+	}
+	fieldErrs := validateTimeLimit(quizPost.Time_limit)
+	if quizPost.Category_id == nil && strings.TrimSpace(quizPost.Category) == "" {
+		fieldErrs = append(fieldErrs, Field_Error{Field: "category", Message: "category is required"})
+	}
+	if len(fieldErrs) > 0 {
+		return validationFailed("Invalid quiz", fieldErrs)
 	}
 
-	queryStr := "INSERT INTO quizzes (title, category) VALUES ($1, $2)"
-	_, err := db.Exec(context.Background(), queryStr, quizPost.Title, quizPost.Category)
+	categoryID, category, err := resolveCategory(context.Background(), db, quizPost.Category_id, quizPost.Category)
 	if err != nil {
-		log.Println("DB Exec error:", err)
-		return c.SendStatus(500)
+		return categoryFailed(err, quizPost.Category_id)
 	}
 
-	return c.Status(201).JSON(fiber.Map{"message": "Quiz added"})
+	queryStr := "INSERT INTO quizzes (title, category, category_id, creator_email, time_limit_seconds) VALUES ($1, $2, $3, $4, $5) RETURNING quiz_id"
+	var quizID uuid.UUID
+	err = db.QueryRow(context.Background(), queryStr, quizPost.Title, category, categoryID, user.Email, quizPost.Time_limit).Scan(&quizID)
+	if err != nil {
+		return internalError("Failed to create quiz", err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{"message": "Quiz added", "id": quizID})
 }
 
 // PatchQuiz godoc
 // @Summary      Update a quiz
-// @Description  Update the title and category of an existing quiz.
+// @Description  Update the title, category and time limit of an existing quiz. Only the fields that are sent change: a category of "" makes the quiz uncategorised and a time_limit of 0 makes it untimed.
 // @Tags         quiz
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Param        id    path      string       true  "Quiz ID"
-// @Param        quiz  body      Quiz_Update  true  "Quiz update data"
+// @Param        quiz  body      Quiz_Update  true  "Fields to change"
 // @Success      200   {object}  Quiz_Update
-// @Failure      400   {object}  map[string]string  "Bad request or invalid quiz ID"
-// @Failure      500   {object}  map[string]string  "Internal server error"
-// @Router       /quiz/{id} [patch]
+// @Failure      400   {object}  API_Error  "Bad request or invalid quiz ID"
+// @Failure      403   {object}  API_Error  "Not the creator or a collaborator"
+// @Failure      404   {object}  API_Error  "Quiz not found"
+// @Failure      422   {object}  API_Error  "Empty title, invalid time limit or unknown category"
+// @Failure      500   {object}  API_Error  "Internal server error"
+// @Router       /quiz/edit/{id} [patch]
 func PatchQuiz(c *fiber.Ctx) error {
 	quizIDStr := c.Params("id")
 	quizID, err := uuid.Parse(quizIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid quiz ID"})
+		return badRequest("Invalid quiz ID")
+	}
+	if err := authorizeQuiz(c, quizID, roleCollaborator); err != nil {
+		return err
 	}
 
 	var quizUpdate Quiz_Update
 	if err := c.BodyParser(&quizUpdate); err != nil {
-		return c.SendStatus(400)
+		return badRequest("Cannot parse JSON")
+	}
+	if fieldErrs := validateQuizUpdate(quizUpdate); len(fieldErrs) > 0 {
+		return validationFailed("Invalid quiz", fieldErrs)
+	}
+	var title *string
+	if quizUpdate.Title != nil {
+		trimmed := strings.TrimSpace(*quizUpdate.Title)
+		title = &trimmed
 	}
 
-	queryStr := "UPDATE quizzes SET title = $2, category = $3 WHERE quiz_id = $1 RETURNING title, category"
-	row := db.QueryRow(context.Background(), queryStr, quizID, quizUpdate.Title, quizUpdate.Category)
+	// sending neither category_id nor category keeps the category
+	setCategory := quizUpdate.Category_id != nil || quizUpdate.Category != nil
+	var categoryID *uuid.UUID
+	var category string
+	if setCategory {
+		var categoryText string
+		if quizUpdate.Category != nil {
+			categoryText = *quizUpdate.Category
+		}
+		categoryID, category, err = resolveCategory(context.Background(), db, quizUpdate.Category_id, categoryText)
+		if err != nil {
+			return categoryFailed(err, quizUpdate.Category_id)
+		}
+	}
+	clearTimeLimit := quizUpdate.Time_limit != nil && *quizUpdate.Time_limit == 0
+
+	// attempts already running keep the deadline they started with
+	queryStr := `
+		UPDATE quizzes
+		SET title = COALESCE($2, title),
+			category = CASE WHEN $5::bool THEN $3 ELSE category END,
+			category_id = CASE WHEN $5::bool THEN $4::uuid ELSE category_id END,
+			time_limit_seconds = CASE WHEN $7::bool THEN NULL ELSE COALESCE($6, time_limit_seconds) END
+		WHERE quiz_id = $1
+		RETURNING title, category, category_id, time_limit_seconds
+	`
+	row := db.QueryRow(context.Background(), queryStr, quizID, title, category, categoryID, setCategory, quizUpdate.Time_limit, clearTimeLimit)
 
-	if err := row.Scan(&quizUpdate.Title, &quizUpdate.Category); err != nil {
-		return c.SendStatus(500)
+	if err := row.Scan(&quizUpdate.Title, &quizUpdate.Category, &quizUpdate.Category_id, &quizUpdate.Time_limit); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// deleted between the permission check and the update
+			return notFound("Quiz not found")
+		}
+		return internalError("Failed to update quiz", err)
 	}
 	return c.JSON(quizUpdate)
 }
 
 // DeleteQuiz godoc
 // @Summary      Delete a quiz
-// @Description  Delete an existing quiz by its ID.
+// @Description  Delete an existing quiz by its ID. Only the quiz creator can do this.
 // @Tags         quiz
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Param        id   path      string  true  "Quiz ID"
 // @Success      200  {object}  map[string]string  "Quiz deleted message"
-// @Failure      400  {object}  map[string]string  "Bad request or invalid quiz ID"
-// @Failure      500  {object}  map[string]string  "Internal server error"
-// @Router       /quiz/{id} [delete]
+// @Failure      400  {object}  API_Error  "Bad request or invalid quiz ID"
+// @Failure      403  {object}  API_Error  "Not the quiz creator"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /quiz/delete/{id} [delete]
 func DeleteQuiz(c *fiber.Ctx) error {
 	quizIDStr := c.Params("id")
 	quizID, err := uuid.Parse(quizIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid quiz ID"})
+		return badRequest("Invalid quiz ID")
+	}
+	if err := authorizeQuiz(c, quizID, roleOwner); err != nil {
+		return err
 	}
 
 	queryStr := "DELETE FROM quizzes WHERE quiz_id = $1"
 	_, err = db.Exec(context.Background(), queryStr, quizID)
 	if err != nil {
-		return c.SendStatus(500)
+		return internalError("Failed to delete quiz", err)
 	}
 
 	return c.Status(200).JSON(fiber.Map{"message": "Quiz deleted", "id": quizID})
 }
 
+// ForkQuiz godoc
+// @Summary      Fork a quiz
+// @Description  Copy a quiz, its tags and all of its questions, in the same order and with the same choices and answers, into a new quiz owned by the caller. The copy records the quiz it came from. Collaborators, attempts and answers aren't copied.
+// @Tags         quiz
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Quiz ID to fork"
+// @Success      201  {object}  map[string]interface{}  "New quiz id and the source quiz id"
+// @Failure      400  {object}  API_Error  "Invalid quiz ID"
+// @Failure      401  {object}  API_Error  "Login required"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      409  {object}  API_Error  "Quiz was deleted during the copy"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /quiz/fork/{id} [post]
+func ForkQuiz(c *fiber.Ctx) error {
+	user, _ := currentUser(c)
+
+	quizIDStr := c.Params("id")
+	sourceID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz ID")
+	}
+
+	// question edits don't lock the quiz row, so a lock here wouldn't keep them out. Instead
+	// every statement reads the same snapshot, and the copy is the quiz as it was when the
+	// fork started even if it's edited or reordered meanwhile.
+	tx, err := db.BeginTx(context.Background(), pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+	if err != nil {
+		return internalError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	queryStr := `
+		INSERT INTO quizzes (title, category, category_id, creator_email, time_limit_seconds, source_quiz_id)
+		SELECT title, category, category_id, $2, time_limit_seconds, quiz_id
+		FROM quizzes
+		WHERE quiz_id = $1
+		RETURNING quiz_id
+	`
+	var quizID uuid.UUID
+	if err := tx.QueryRow(context.Background(), queryStr, sourceID, user.Email).Scan(&quizID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Quiz not found")
+		}
+		if isSerializationFailure(err) {
+			return conflict("Quiz was deleted while it was being copied")
+		}
+		return internalError("Failed to copy quiz", err)
+	}
+
+	queryStr = `
+		INSERT INTO questions (quiz_id, position, type, message, choices, answer_tf, correct_choice, correct_answers,
+		                       fib_options, correct_choices, scoring_mode, points, penalty)
+		SELECT $2, position, type, message, choices, answer_tf, correct_choice, correct_answers,
+		       fib_options, correct_choices, scoring_mode, points, penalty
+		FROM questions
+		WHERE quiz_id = $1
+	`
+	if _, err := tx.Exec(context.Background(), queryStr, sourceID, quizID); err != nil {
+		return internalError("Failed to copy questions", err)
+	}
+
+	queryStr = "INSERT INTO quiz_tags (quiz_id, tag_id) SELECT $2, tag_id FROM quiz_tags WHERE quiz_id = $1"
+	if _, err := tx.Exec(context.Background(), queryStr, sourceID, quizID); err != nil {
+		return internalError("Failed to copy tags", err)
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return internalError("Failed to commit transaction", err)
+	}
+	return c.Status(201).JSON(fiber.Map{"message": "Quiz forked", "id": quizID, "source_quiz_id": sourceID})
+}
+
 // GetQuestionsByQuizId godoc
 // @Summary      Get question IDs for a quiz
 // @Description  Retrieve an ordered list of question IDs for a specific quiz.
@@ -182,30 +370,35 @@ func DeleteQuiz(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id   path      string  true  "Quiz ID"
 // @Success      200  {array}   string
-// @Failure      400  {object}  map[string]string  "Invalid quiz id"
-// @Failure      500  {object}  map[string]string  "Error fetching questions"
+// @Failure      400  {object}  API_Error  "Invalid quiz id"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      500  {object}  API_Error  "Error fetching questions"
 // @Router       /quiz/question/{id} [get]
 func GetQuestionsByQuizId(c *fiber.Ctx) error {
 	quizIDStr := c.Params("id")
 	quizID, err := uuid.Parse(quizIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid quiz id"})
+		return badRequest("Invalid quiz id")
+	}
+
+	if exists, err := quizExists(context.Background(), db, quizID); err != nil {
+		return internalError("Failed to fetch quiz", err)
+	} else if !exists {
+		return notFound("Quiz not found")
 	}
 
 	queryStr := "SELECT question_id FROM questions WHERE quiz_id = $1 ORDER BY position"
 	rows, err := db.Query(context.Background(), queryStr, quizID)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(fiber.Map{"error": "Error fetching questions"})
+		return internalError("Error fetching questions", err)
 	}
 	defer rows.Close()
 
-	var questionIDs []string
+	questionIDs := []string{}
 	for rows.Next() {
 		var qid uuid.UUID
 		if err := rows.Scan(&qid); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(fiber.Map{"error": "Error scanning question id"})
+			return internalError("Error scanning question id", err)
 		}
 		questionIDs = append(questionIDs, qid.String()) // eror klo ga .String()
 	}
@@ -213,6 +406,22 @@ func GetQuestionsByQuizId(c *fiber.Ctx) error {
 	return c.JSON(questionIDs)
 }
 
+// quizExists tells an unknown quiz apart from one that just has nothing to list yet.
+func quizExists(ctx context.Context, q dbtx, quizID uuid.UUID) (bool, error) {
+	var exists bool
+	err := q.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM quizzes WHERE quiz_id = $1)", quizID).Scan(&exists)
+	return exists, err
+}
+
+// questionColumns is the column list scanQuestion expects, in order.
+const questionColumns = "quiz_id, question_id, position, type, message, choices, answer_tf, correct_choice, correct_answers, fib_options, correct_choices, COALESCE(scoring_mode, ''), points, penalty"
+
+func scanQuestion(row pgx.Row, question *Question) error {
+	return row.Scan(&question.Quiz_id, &question.Question_id, &question.Position, &question.Type,
+		&question.Message, &question.Choices, &question.Answer_tf, &question.Correct_choice, &question.Correct_answers,
+		&question.Fib_options, &question.Correct_choices, &question.Scoring_mode, &question.Points, &question.Penalty)
+}
+
 // GetQuestion godoc
 // @Summary      Get a single question
 // @Description  Retrieve the details of a question by its ID.
@@ -221,77 +430,101 @@ func GetQuestionsByQuizId(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id   path      string  true  "Question ID"
 // @Success      200  {object}  Question
-// @Failure      400  {object}  map[string]string  "Invalid question ID"
-// @Failure      404  {object}  map[string]string  "Question not found"
+// @Failure      400  {object}  API_Error  "Invalid question ID"
+// @Failure      404  {object}  API_Error  "Question not found"
 // @Router       /question/{id} [get]
 func GetQuestion(c *fiber.Ctx) error {
 	questionIDStr := c.Params("id")
 	questionID, err := uuid.Parse(questionIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid question ID"})
+		return badRequest("Invalid question ID")
 	}
 
 	// Build the SQL query.
-	queryStr := `
-		SELECT quiz_id, question_id, position, type, message, choices, answer_tf, correct_choice, correct_answers 
-		FROM questions 
-		WHERE question_id = $1
-	`
+	queryStr := `SELECT ` + questionColumns + ` FROM questions WHERE question_id = $1`
 
 	// Query the database.
 	row := db.QueryRow(context.Background(), queryStr, questionID)
 	var question Question
-	err = row.Scan(&question.Quiz_id, &question.Question_id, &question.Position, &question.Type,
-		&question.Message, &question.Choices, &question.Answer_tf, &question.Correct_choice, &question.Correct_answers)
+	err = scanQuestion(row, &question)
 	if err != nil {
-		log.Println(err)
-		return c.SendStatus(404)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Question not found")
+		}
+		return internalError("Failed to fetch question", err)
 	}
 	return c.JSON(question)
 }
 
 // PostQuestionByQuizId godoc
 // @Summary      Add a new question to a quiz
-// @Description  Add a new question at the end of the question list for a specified quiz. The position is set as the current count of questions for the quiz plus one.
+// @Description  Add a new question to a quiz, at the end of the question list unless a 1-based position is given, in which case the questions from there on move down one. Without a body the question starts as a blank 'tf' question, with one it has to be a valid question.
 // @Tags         quiz, question
 // @Accept       json
 // @Produce      json
-// @Param        id   path      string  true  "Quiz ID"
+// @Security     BearerAuth
+// @Param        id        path      string           true   "Quiz ID"
+// @Param        position  query     int              false  "Position to insert at, defaults to the end"
+// @Param        body      body      Question_Update  false  "Question content"
 // @Success      201  {object}  map[string]interface{}  "New question details including question_id and position"
-// @Failure      400  {object}  map[string]string       "Invalid quiz ID"
-// @Failure      500  {object}  map[string]string       "Error getting question count or inserting new question"
-// @Router       /quiz/{id}/question [post]
+// @Failure      400  {object}  API_Error  "Invalid quiz ID or JSON payload"
+// @Failure      403  {object}  API_Error  "Not the creator or a collaborator"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      422  {object}  API_Error  "Position out of range or question fails validation"
+// @Failure      500  {object}  API_Error  "Error getting question count or inserting new question"
+// @Router       /question/create/{id} [post]
 func PostQuestionByQuizId(c *fiber.Ctx) error {
 	// Parse and validate the quiz ID from the URL.
 	quizIDStr := c.Params("id")
 	quizID, err := uuid.Parse(quizIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid quiz id"})
+		return badRequest("Invalid quiz id")
+	}
+	if err := authorizeQuiz(c, quizID, roleCollaborator); err != nil {
+		return err
 	}
 
-	var count int
-	countQuery := "SELECT COUNT(*) FROM questions WHERE quiz_id = $1"
-	err = db.QueryRow(context.Background(), countQuery, quizID).Scan(&count)
+	//default vals, a blank question the editor fills in afterwards
+	question := Question_Update{Type: "tf", Message: ""}
+	if len(bytes.TrimSpace(c.Body())) > 0 {
+		if err := c.BodyParser(&question); err != nil {
+			return badRequest("Cannot parse JSON")
+		}
+		if fieldErrs := validateQuestion(question); len(fieldErrs) > 0 {
+			return validationFailed("Invalid question", fieldErrs)
+		}
+	}
+
+	tx, err := db.Begin(context.Background())
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to get question count"})
+		return internalError("Failed to start transaction", err)
 	}
-	newPos := count + 1
+	defer tx.Rollback(context.Background())
 
-	//default vals
-	defaultType := "tf"
-	defaultMessage := ""
+	// the lock makes the count below safe to build on until commit
+	count, err := lockQuizOrder(context.Background(), tx, quizID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Quiz not found")
+		}
+		return internalError("Failed to get question count", err)
+	}
 
-	insertQuery := `
-		INSERT INTO questions (quiz_id, position, type, message)
-		VALUES ($1, $2, $3, $4)
-		RETURNING question_id
-	`
-	var newQuestionID uuid.UUID
-	err = db.QueryRow(context.Background(), insertQuery, quizID, newPos, defaultType, defaultMessage).Scan(&newQuestionID)
+	newPos, fieldErrs := insertPosition(c, count)
+	if len(fieldErrs) > 0 {
+		return validationFailed("Invalid position", fieldErrs)
+	}
+	if err := shiftPositions(context.Background(), tx, quizID, newPos, 1); err != nil {
+		return internalError("Failed to update positions", err)
+	}
+
+	newQuestionID, err := insertQuestion(context.Background(), tx, quizID, newPos, question)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to insert new question"})
+		return internalError("Failed to insert new question", err)
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return internalError("Failed to commit transaction", err)
 	}
 
 	return c.Status(201).JSON(fiber.Map{
@@ -300,28 +533,157 @@ func PostQuestionByQuizId(c *fiber.Ctx) error {
 	})
 }
 
+// PostQuestionBatchByQuizId godoc
+// @Summary      Add several questions to a quiz
+// @Description  Add a list of complete questions in one transaction, in order, at the end of the quiz or from a 1-based position. Either every question is valid and they're all created, or nothing is.
+// @Tags         quiz, question
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string             true   "Quiz ID"
+// @Param        position  query     int                false  "Position to insert the first question at, defaults to the end"
+// @Param        body      body      []Question_Update  true   "Questions to create"
+// @Success      201  {array}   map[string]interface{}  "question_id and position of each new question"
+// @Failure      400  {object}  API_Error  "Invalid quiz ID or JSON payload"
+// @Failure      403  {object}  API_Error  "Not the creator or a collaborator"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      422  {object}  API_Error  "Position out of range or questions fail validation, fields are prefixed with the question's index"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /question/batch/{id} [post]
+func PostQuestionBatchByQuizId(c *fiber.Ctx) error {
+	quizIDStr := c.Params("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz id")
+	}
+	if err := authorizeQuiz(c, quizID, roleCollaborator); err != nil {
+		return err
+	}
+
+	questions := []Question_Update{}
+	if err := c.BodyParser(&questions); err != nil {
+		return badRequest("Cannot parse JSON")
+	}
+	if len(questions) == 0 {
+		return validationFailed("Invalid questions", []Field_Error{{Field: "questions", Message: "at least one question is required"}})
+	}
+	var fieldErrs []Field_Error
+	for i, question := range questions {
+		fieldErrs = append(fieldErrs, prefixFieldErrors(fmt.Sprintf("[%d]", i), validateQuestion(question))...)
+	}
+	if len(fieldErrs) > 0 {
+		return validationFailed("Invalid questions", fieldErrs)
+	}
+
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		return internalError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	count, err := lockQuizOrder(context.Background(), tx, quizID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Quiz not found")
+		}
+		return internalError("Failed to get question count", err)
+	}
+
+	firstPos, fieldErrs := insertPosition(c, count)
+	if len(fieldErrs) > 0 {
+		return validationFailed("Invalid position", fieldErrs)
+	}
+	if err := shiftPositions(context.Background(), tx, quizID, firstPos, len(questions)); err != nil {
+		return internalError("Failed to update positions", err)
+	}
+
+	created := make([]fiber.Map, len(questions))
+	for i, question := range questions {
+		questionID, err := insertQuestion(context.Background(), tx, quizID, firstPos+i, question)
+		if err != nil {
+			return internalError("Failed to insert new question", err)
+		}
+		created[i] = fiber.Map{"question_id": questionID.String(), "position": firstPos + i}
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return internalError("Failed to commit transaction", err)
+	}
+	return c.Status(201).JSON(created)
+}
+
+// insertPosition reads the optional ?position= for a new question, defaulting to the end
+// of a quiz with count questions.
+func insertPosition(c *fiber.Ctx, count int) (int, []Field_Error) {
+	positionStr := c.Query("position")
+	if positionStr == "" {
+		return count + 1, nil
+	}
+	position, err := strconv.Atoi(positionStr)
+	if err != nil || position < 1 || position > count+1 {
+		return 0, []Field_Error{{Field: "position", Message: fmt.Sprintf("position must be a number between 1 and %d", count+1)}}
+	}
+	return position, nil
+}
+
+// insertQuestion writes a question's content at the given position. Making room there
+// is up to the caller, inside the same transaction and with the quiz locked.
+func insertQuestion(ctx context.Context, q dbtx, quizID uuid.UUID, position int, question Question_Update) (uuid.UUID, error) {
+	insertQuery := `
+		INSERT INTO questions (quiz_id, position, type, message, choices, answer_tf, correct_choice, correct_answers,
+		                       fib_options, correct_choices, scoring_mode, points, penalty)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), COALESCE($12, 1), COALESCE($13, 0))
+		RETURNING question_id
+	`
+	var questionID uuid.UUID
+	err := q.QueryRow(ctx, insertQuery, quizID, position,
+		question.Type,
+		question.Message,
+		question.Choices,
+		question.Answer_tf,
+		question.Correct_choice,
+		question.Correct_answers,
+		question.Fib_options,
+		question.Correct_choices,
+		question.Scoring_mode,
+		question.Points,
+		question.Penalty,
+	).Scan(&questionID)
+	return questionID, err
+}
+
 // PatchQuestion godoc
 // @Summary      Update a question
-// @Description  Update specific fields of an existing question by its ID. The quiz_id remains unchanged.
+// @Description  Replace the content of an existing question by its ID. The quiz_id and position remain unchanged, and the content has to be valid for its type.
 // @Tags         question
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Param        id    path      string           true  "Question ID"
 // @Param        body  body      Question_Update  true  "Fields to update for the question"
 // @Success      200  {object}  map[string]string  "Success status message"
-// @Failure      400  {object}  map[string]string  "Invalid question ID or JSON payload"
-// @Failure      500  {object}  map[string]string  "Failed to update question"
-// @Router       /question/{id} [patch]
+// @Failure      400  {object}  API_Error  "Invalid question ID or JSON payload"
+// @Failure      403  {object}  API_Error  "Not the creator or a collaborator"
+// @Failure      404  {object}  API_Error  "Question not found"
+// @Failure      422  {object}  API_Error  "Question fails validation, with the problems listed under fields"
+// @Failure      500  {object}  API_Error  "Failed to update question"
+// @Router       /question/edit/{id} [patch]
 func PatchQuestion(c *fiber.Ctx) error {
 	questionIDStr := c.Params("id")
 	questionID, err := uuid.Parse(questionIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid question ID"})
+		return badRequest("Invalid question ID")
+	}
+	if err := authorizeQuestion(c, questionID, roleCollaborator); err != nil {
+		return err
 	}
 
 	var questionUpdate Question_Update
 	if err := c.BodyParser(&questionUpdate); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		return badRequest("Cannot parse JSON")
+	}
+	if fieldErrs := validateQuestion(questionUpdate); len(fieldErrs) > 0 {
+		return validationFailed("Invalid question", fieldErrs)
 	}
 
 	queryStr := `
@@ -332,21 +694,34 @@ func PatchQuestion(c *fiber.Ctx) error {
 		    choices = $3,
 		    answer_tf = $4,
 		    correct_choice = $5,
-		    correct_answers = $6
-		WHERE question_id = $7
+		    correct_answers = $6,
+		    fib_options = $7,
+		    correct_choices = $8,
+		    scoring_mode = NULLIF($9, ''),
+		    points = COALESCE($10, points),
+		    penalty = COALESCE($11, penalty)
+		WHERE question_id = $12
 	`
-	_, err = db.Exec(context.Background(), queryStr,
+	tag, err := db.Exec(context.Background(), queryStr,
 		questionUpdate.Type,
 		questionUpdate.Message,
 		questionUpdate.Choices,
 		questionUpdate.Answer_tf,
 		questionUpdate.Correct_choice,
 		questionUpdate.Correct_answers,
+		questionUpdate.Fib_options,
+		questionUpdate.Correct_choices,
+		questionUpdate.Scoring_mode,
+		questionUpdate.Points,
+		questionUpdate.Penalty,
 		questionID,
 	)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update question"})
+		return internalError("Failed to update question", err)
+	}
+	if tag.RowsAffected() == 0 {
+		// deleted between the permission check and the update
+		return notFound("Question not found")
 	}
 	return c.JSON(fiber.Map{"status": "success"})
 }
@@ -357,201 +732,397 @@ func PatchQuestion(c *fiber.Ctx) error {
 // @Tags         question
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Param        id   path      string  true  "Question ID"
 // @Success      200  {object}  map[string]string  "Status message indicating deletion"
-// @Failure      400  {object}  map[string]string  "Invalid question ID"
-// @Failure      404  {object}  map[string]string  "Question not found"
-// @Failure      500  {object}  map[string]string  "Error during deletion or position update"
-// @Router       /question/{id} [delete]
+// @Failure      400  {object}  API_Error  "Invalid question ID"
+// @Failure      403  {object}  API_Error  "Not the creator or a collaborator"
+// @Failure      404  {object}  API_Error  "Question not found"
+// @Failure      500  {object}  API_Error  "Error during deletion or position update"
+// @Router       /question/delete/{id} [delete]
 func DeleteQuestion(c *fiber.Ctx) error {
 	questionIDStr := c.Params("id")
 	questionID, err := uuid.Parse(questionIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid question ID"})
+		return badRequest("Invalid question ID")
+	}
+	if err := authorizeQuestion(c, questionID, roleCollaborator); err != nil {
+		return err
 	}
 
 	tx, err := db.Begin(context.Background())
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to start transaction"})
+		return internalError("Failed to start transaction", err)
 	}
 	defer tx.Rollback(context.Background())
 
 	var quizID uuid.UUID
+	selectQuery := `SELECT quiz_id FROM questions WHERE question_id = $1`
+	err = tx.QueryRow(context.Background(), selectQuery, questionID).Scan(&quizID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Question not found")
+		}
+		return internalError("Failed to fetch question", err)
+	}
+	if _, err := lockQuizOrder(context.Background(), tx, quizID); err != nil {
+		return internalError("Failed to lock quiz", err)
+	}
+
+	// read the position only once locked, a reorder may have moved it in the meantime
 	var pos int
-	selectQuery := `SELECT quiz_id, position FROM questions WHERE question_id = $1`
-	err = tx.QueryRow(context.Background(), selectQuery, questionID).Scan(&quizID, &pos)
+	err = tx.QueryRow(context.Background(), `SELECT position FROM questions WHERE question_id = $1`, questionID).Scan(&pos)
 	if err != nil {
-		log.Println(err)
-		return c.Status(404).JSON(fiber.Map{"error": "Question not found"})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Question not found")
+		}
+		return internalError("Failed to fetch question", err)
 	}
 
 	deleteQuery := `DELETE FROM questions WHERE question_id = $1`
 	_, err = tx.Exec(context.Background(), deleteQuery, questionID)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete question"})
+		return internalError("Failed to delete question", err)
 	}
 
-	updateQuery := `
-		UPDATE questions 
-		SET position = position - 1 
-		WHERE quiz_id = $1 AND position > $2
-	`
-	_, err = tx.Exec(context.Background(), updateQuery, quizID, pos)
+	err = shiftPositions(context.Background(), tx, quizID, pos+1, -1)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update positions"})
+		return internalError("Failed to update positions", err)
 	}
 
 	if err = tx.Commit(context.Background()); err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to commit transaction"})
+		return internalError("Failed to commit transaction", err)
 	}
 
 	return c.JSON(fiber.Map{"status": "deleted"})
 }
 
+// PostAttemptByQuizId godoc
+// @Summary      Start an attempt
+// @Description  Start an attempt at a quiz. A timed quiz fixes the deadline now, so later changes to the time limit don't move it.
+// @Tags         submission
+// @Produce      json
+// @Param        id   path      string  true  "Quiz ID"
+// @Success      200  {object}  map[string]interface{}  "attempt_id, started_at and deadline (null when untimed)"
+// @Failure      400  {object}  API_Error  "Invalid quiz ID"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /submission/attempt/{id} [post]
 func PostAttemptByQuizId(c *fiber.Ctx) error {
 	quizIDStr := c.Params("id")
 	quizID, err := uuid.Parse(quizIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid quiz ID"})
+		return badRequest("Invalid quiz ID")
 	}
 
-	queryStr := `INSERT INTO Submission_attempts (quiz_id) VALUES ($1) RETURNING attempt_id`
+	// the deadline is fixed when the attempt starts so changing the time limit later can't move it
+	queryStr := `
+		INSERT INTO submission_attempts (quiz_id, started_at, deadline)
+		SELECT quiz_id, now(), now() + time_limit_seconds * interval '1 second'
+		FROM quizzes
+		WHERE quiz_id = $1
+		RETURNING attempt_id, started_at, deadline
+	`
 	var attemptID uuid.UUID
-	err = db.QueryRow(context.Background(), queryStr, quizID).Scan(&attemptID)
+	var startedAt time.Time
+	var deadline *time.Time
+	err = db.QueryRow(context.Background(), queryStr, quizID).Scan(&attemptID, &startedAt, &deadline)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to insert new attempt"})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Quiz not found")
+		}
+		return internalError("Failed to insert new attempt", err)
 	}
-	return c.Status(200).JSON(fiber.Map{"attempt_id": attemptID})
+	return c.Status(200).JSON(fiber.Map{"attempt_id": attemptID, "started_at": startedAt, "deadline": deadline})
 }
 
+// PutAnswerByAttemptId godoc
+// @Summary      Save an answer
+// @Description  Save or replace the answer to one question of an attempt that is still in progress and before its deadline.
+// @Tags         submission
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string             true  "Attempt ID"
+// @Param        answer  body      Submission_answer  true  "Answer, question_id says which question"
+// @Success      200     {object}  map[string]string  "Answer saved"
+// @Failure      400     {object}  API_Error  "Invalid attempt ID, JSON payload, or a question from another quiz"
+// @Failure      404     {object}  API_Error  "Attempt not found"
+// @Failure      409     {object}  API_Error  "Attempt already submitted or its deadline has passed"
+// @Failure      500     {object}  API_Error  "Internal server error"
+// @Router       /submission/answer/{id} [put]
 func PutAnswerByAttemptId(c *fiber.Ctx) error {
 	attemptIDStr := c.Params("id")
 	attemptID, err := uuid.Parse(attemptIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid attempt ID"})
+		return badRequest("Invalid attempt ID")
 	}
 
 	var submission Submission_answer
 	if err := c.BodyParser(&submission); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		return badRequest("Cannot parse JSON")
+	}
+
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		return internalError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	attempt, err := lockAttempt(context.Background(), tx, attemptID, "FOR SHARE")
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Attempt not found")
+		}
+		return internalError("Failed to fetch attempt", err)
+	}
+	if attempt.Status != attemptInProgress {
+		return conflict("Attempt is already submitted").withDetails(fiber.Map{"status": attempt.Status})
+	}
+	if attempt.Expired {
+		return conflict("Attempt deadline has passed")
+	}
+
+	var inQuiz bool
+	inQuizQuery := `SELECT EXISTS (SELECT 1 FROM questions WHERE question_id = $1 AND quiz_id = $2)`
+	if err := tx.QueryRow(context.Background(), inQuizQuery, submission.Question_id, attempt.Quiz_id).Scan(&inQuiz); err != nil {
+		return internalError("Failed to check question", err)
+	}
+	if !inQuiz {
+		return badRequest("Question is not part of this attempt's quiz")
 	}
 
 	queryStr := `
-      INSERT INTO submission_answers (attempt_id, question_id, answer_tf, correct_choice, correct_answers)
-      VALUES ($1, $2, $3, $4, $5)
+      INSERT INTO submission_answers (attempt_id, question_id, answer_tf, correct_choice, correct_answers, selected_choices)
+      VALUES ($1, $2, $3, $4, $5, $6)
       ON CONFLICT (attempt_id, question_id) DO UPDATE
       SET answer_tf = EXCLUDED.answer_tf,
           correct_choice = EXCLUDED.correct_choice,
-          correct_answers = EXCLUDED.correct_answers;
+          correct_answers = EXCLUDED.correct_answers,
+          selected_choices = EXCLUDED.selected_choices;
     `
-	_, err = db.Exec(context.Background(), queryStr,
+	_, err = tx.Exec(context.Background(), queryStr,
 		attemptID,
 		submission.Question_id,
 		submission.Answer_tf,
 		submission.Correct_choice,
 		submission.Correct_answers,
+		submission.Selected_choices,
 	)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update answer"})
+		return internalError("Failed to update answer", err)
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return internalError("Failed to commit transaction", err)
 	}
 	return c.Status(200).JSON(fiber.Map{"status": "success"})
 }
 
+// GetAnswer godoc
+// @Summary      Get a saved answer
+// @Description  The answer saved for one question of an attempt.
+// @Tags         submission
+// @Produce      json
+// @Param        attemptid   path      string  true  "Attempt ID"
+// @Param        questionid  path      string  true  "Question ID"
+// @Success      200         {object}  Submission_answer
+// @Failure      400         {object}  API_Error  "Invalid attempt or question ID"
+// @Failure      404         {object}  API_Error  "Question not answered in this attempt"
+// @Failure      500         {object}  API_Error  "Internal server error"
+// @Router       /submission/{attemptid}/{questionid} [get]
 func GetAnswer(c *fiber.Ctx) error {
 	attemptIDStr := c.Params("attemptid")
-	questionID := c.Params("questionid")
 	attemptID, err := uuid.Parse(attemptIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid attempt ID"})
+		return badRequest("Invalid attempt ID")
+	}
+	questionID, err := uuid.Parse(c.Params("questionid"))
+	if err != nil {
+		return badRequest("Invalid question ID")
 	}
 
 	var submission Submission_answer
 	queryStr := `
-        SELECT answer_tf, correct_choice, correct_answers
+        SELECT answer_tf, correct_choice, correct_answers, selected_choices
         FROM submission_answers
         WHERE attempt_id = $1 AND question_id = $2
     `
 	err = db.QueryRow(context.Background(), queryStr, attemptID, questionID).
-		Scan(&submission.Answer_tf, &submission.Correct_choice, &submission.Correct_answers)
+		Scan(&submission.Answer_tf, &submission.Correct_choice, &submission.Correct_answers, &submission.Selected_choices)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return c.Status(200).JSON(fiber.Map{})
+		if errors.Is(err, pgx.ErrNoRows) {
+			// the question hasn't been answered in this attempt yet
+			return notFound("Answer not found")
 		}
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to get answer"})
+		return internalError("Failed to get answer", err)
 	}
 	return c.JSON(submission)
 }
 
+// CompleteAttempt godoc
+// @Summary      Submit an attempt
+// @Description  Close an attempt and grade it once, the score is stored so reads never recompute it.
+// @Tags         submission
+// @Produce      json
+// @Param        attemptid  path      string  true  "Attempt ID"
+// @Success      200        {object}  map[string]interface{}  "status, score and total"
+// @Failure      400        {object}  API_Error  "Invalid attempt ID"
+// @Failure      404        {object}  API_Error  "Attempt not found"
+// @Failure      409        {object}  API_Error  "Attempt already submitted"
+// @Failure      500        {object}  API_Error  "Internal server error"
+// @Router       /submission/attempt/complete/{attemptid} [put]
 func CompleteAttempt(c *fiber.Ctx) error {
 	attemptIDStr := c.Params("attemptid")
 	attemptID, err := uuid.Parse(attemptIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid attempt ID"})
+		return badRequest("Invalid attempt ID")
+	}
+
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		return internalError("Failed to start transaction", err)
 	}
-	queryStr := `UPDATE submission_attempts SET completed_at = NOW() WHERE attempt_id = $1`
-	_, err = db.Exec(context.Background(), queryStr, attemptID)
+	defer tx.Rollback(context.Background())
+
+	attempt, err := lockAttempt(context.Background(), tx, attemptID, "FOR UPDATE")
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to complete attempt"})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Attempt not found")
+		}
+		return internalError("Failed to fetch attempt", err)
+	}
+	if attempt.Status != attemptInProgress {
+		return conflict("Attempt is already submitted").withDetails(fiber.Map{"status": attempt.Status})
 	}
-	return c.JSON(fiber.Map{"status": "completed"})
+
+	// grade once here so reads never have to recompute the score
+	score, total, err := finishAttempt(context.Background(), tx, attemptID)
+	if err != nil {
+		if errors.Is(err, errAttemptState) {
+			return conflict("Attempt is already submitted")
+		}
+		return internalError("Failed to complete attempt", err)
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return internalError("Failed to commit transaction", err)
+	}
+	return c.JSON(fiber.Map{"status": attemptGraded, "score": score, "total": total})
 }
 
+// GetLatestSubmissions godoc
+// @Summary      Get the latest results of a quiz
+// @Description  The five most recently graded attempts at a quiz, newest first.
+// @Tags         submission
+// @Produce      json
+// @Param        id   path      string  true  "Quiz ID"
+// @Success      200  {array}   Submission_Result
+// @Failure      400  {object}  API_Error  "Invalid quiz ID"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /submission/latest/{id} [get]
 func GetLatestSubmissions(c *fiber.Ctx) error {
 	quizIDStr := c.Params("id")
 	quizID, err := uuid.Parse(quizIDStr)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid quiz ID"})
+		return badRequest("Invalid quiz ID")
 	}
+	if exists, err := quizExists(context.Background(), db, quizID); err != nil {
+		return internalError("Failed to fetch quiz", err)
+	} else if !exists {
+		return notFound("Quiz not found")
+	}
+
 	queryStr := `
-      SELECT sa.attempt_id, sa.completed_at,
-             (SELECT COUNT(*) FROM questions q WHERE q.quiz_id = $1) as total,
-             (
-                SELECT COUNT(*) 
-                FROM submission_answers sub
-                JOIN questions q ON sub.question_id = q.question_id
-                WHERE sub.attempt_id = sa.attempt_id
-                  AND (
-                      (q.type = 'tf' AND sub.answer_tf = q.answer_tf)
-                      OR (q.type = 'mc' AND sub.correct_choice = q.correct_choice)
-                      OR (q.type = 'fib' AND sub.correct_answers = q.correct_answers)
-                  )
-             ) as score
+      SELECT sa.attempt_id, sa.completed_at, COALESCE(sa.total, 0), COALESCE(sa.score, 0)
       FROM submission_attempts sa
       WHERE sa.quiz_id = $1
-        AND sa.completed_at IS NOT NULL
+        AND sa.status = 'graded'
       ORDER BY sa.completed_at DESC
       LIMIT 5;
     `
 	rows, err := db.Query(context.Background(), queryStr, quizID)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch latest submissions"})
+		return internalError("Failed to fetch latest submissions", err)
 	}
 	defer rows.Close()
 
-	type SubmissionResult struct {
-		AttemptID   uuid.UUID `json:"attempt_id"`
-		CompletedAt string    `json:"completed_at"`
-		Total       int       `json:"total"`
-		Score       int       `json:"score"`
-	}
-	var results []SubmissionResult
+	results := []Submission_Result{}
 	for rows.Next() {
-		var res SubmissionResult
+		var res Submission_Result
 		var completedAt time.Time
-		if err := rows.Scan(&res.AttemptID, &completedAt, &res.Total, &res.Score); err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Failed to scan submission result"})
+		if err := rows.Scan(&res.Attempt_id, &completedAt, &res.Total, &res.Score); err != nil {
+			return internalError("Failed to scan submission result", err)
 		}
 		//format as "HH:MM DD Month YYYY"
-		res.CompletedAt = completedAt.Format("15:04 02 January 2006")
+		res.Completed_at = completedAt.Format("15:04 02 January 2006")
 		results = append(results, res)
 	}
 	return c.JSON(results)
 }
+
+// GetAttemptResult godoc
+// @Summary      Get the graded breakdown of an attempt
+// @Description  Every question of the quiz in position order with the submitted answer, the correct answer, whether it was right and the points earned, all as they were when the attempt was graded.
+// @Tags         submission
+// @Produce      json
+// @Param        attemptid  path      string  true  "Attempt ID"
+// @Success      200        {object}  Attempt_Result
+// @Failure      400        {object}  API_Error  "Invalid attempt ID"
+// @Failure      404        {object}  API_Error  "Attempt not found"
+// @Failure      409        {object}  API_Error  "Attempt not graded yet"
+// @Failure      500        {object}  API_Error  "Internal server error"
+// @Router       /submission/result/{attemptid} [get]
+func GetAttemptResult(c *fiber.Ctx) error {
+	attemptIDStr := c.Params("attemptid")
+	attemptID, err := uuid.Parse(attemptIDStr)
+	if err != nil {
+		return badRequest("Invalid attempt ID")
+	}
+
+	result := Attempt_Result{Attempt_id: attemptID, Questions: []Question_Result{}}
+	var status string
+	queryStr := `
+		SELECT quiz_id, status, started_at, COALESCE(completed_at, now()), COALESCE(score, 0), COALESCE(total, 0)
+		FROM submission_attempts
+		WHERE attempt_id = $1
+	`
+	err = db.QueryRow(context.Background(), queryStr, attemptID).
+		Scan(&result.Quiz_id, &status, &result.Started_at, &result.Completed_at, &result.Score, &result.Total)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Attempt not found")
+		}
+		return internalError("Failed to fetch attempt", err)
+	}
+	if status != attemptGraded {
+		return conflict("Attempt has not been graded yet").withDetails(fiber.Map{"status": status})
+	}
+
+	// the snapshot taken at grading time, so the breakdown adds up to the score and shows
+	// the questions as they were answered even if they've been edited since
+	queryStr = `
+		SELECT question, answer, credit, points, max_points
+		FROM attempt_grades
+		WHERE attempt_id = $1
+		ORDER BY position
+	`
+	rows, err := db.Query(context.Background(), queryStr, attemptID)
+	if err != nil {
+		return internalError("Failed to fetch attempt grades", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var questionResult Question_Result
+		var credit float64
+		if err := rows.Scan(&questionResult.Question, &questionResult.Submitted, &credit, &questionResult.Points, &questionResult.Max_points); err != nil {
+			return internalError("Failed to scan attempt grade", err)
+		}
+		questionResult.Is_correct = credit >= 1
+		result.Questions = append(result.Questions, questionResult)
+	}
+	if err := rows.Err(); err != nil {
+		return internalError("Failed to fetch attempt grades", err)
+	}
+	return c.JSON(result)
+}
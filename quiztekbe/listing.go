@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// quizSorts maps the sort query param to the expression quizzes are ordered by, the
+// type to cast a cursor key back to, and the default direction.
+var quizSorts = map[string]struct {
+	expr     string
+	castType string
+	desc     bool
+}{
+	"created_at": {"q.created_at", "timestamptz", true},
+	"title":      {"COALESCE(q.title, '')", "text", false},
+	"popularity": {"(SELECT COUNT(*) FROM submission_attempts sa WHERE sa.quiz_id = q.quiz_id)", "bigint", true},
+}
+
+// quizFilter collects WHERE conditions and their parameters so filters can be combined.
+type quizFilter struct {
+	where  []string
+	params []any
+}
+
+// add appends a condition, with %s standing for the placeholder of arg.
+func (f *quizFilter) add(cond string, arg any) {
+	f.params = append(f.params, arg)
+	f.where = append(f.where, fmt.Sprintf(cond, "$"+strconv.Itoa(len(f.params))))
+}
+
+// placeholder adds arg as a parameter without a condition and returns its placeholder.
+func (f *quizFilter) placeholder(arg any) string {
+	f.params = append(f.params, arg)
+	return "$" + strconv.Itoa(len(f.params))
+}
+
+func (f *quizFilter) sql() string {
+	if len(f.where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(f.where, " AND ")
+}
+
+// quizCursor is where the previous page stopped. It's handed out opaque, and only
+// valid for the sort and order it was made with.
+type quizCursor struct {
+	Sort  string    `json:"s"`
+	Desc  bool      `json:"d"`
+	Key   string    `json:"k"`
+	After uuid.UUID `json:"id"`
+}
+
+func (cur quizCursor) encode() string {
+	data, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeQuizCursor(s string) (quizCursor, error) {
+	var cur quizCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cur, err
+	}
+	err = json.Unmarshal(data, &cur)
+	return cur, err
+}
+
+// tagNames is the subquery of tag names given as %[1]s, normalised the way tags are stored.
+const tagNames = "(SELECT category_slug(name) FROM unnest(%[1]s::text[]) name WHERE category_slug(name) <> '')"
+
+// quizListFilter reads the GET /quiz filters. Each one narrows the result, so any
+// combination works.
+func quizListFilter(c *fiber.Ctx) (quizFilter, []Field_Error) {
+	var f quizFilter
+	var errs []Field_Error
+	// %something% and ILIKE is sql wildcard
+	if title := c.Query("title"); title != "" {
+		f.add("q.title ILIKE %s", "%"+title+"%")
+	}
+	if category := c.Query("category"); category != "" {
+		f.add("q.category_id IN "+fmt.Sprintf(categorySubtree, "%[1]s"), category)
+	}
+	if date := c.Query("date"); date != "" {
+		f.add("to_char(q.created_at, 'DD FMMonth YYYY') ILIKE %s", "%"+date+"%")
+	}
+	if creator := c.Query("creator"); creator != "" {
+		f.add("q.creator_email = %s", normalizeEmail(creator))
+	}
+	if tagsStr := c.Query("tags"); tagsStr != "" {
+		tags := strings.Split(tagsStr, ",")
+		switch c.Query("tag_mode", "all") {
+		case "all":
+			f.add(`q.quiz_id IN (
+				SELECT qt.quiz_id
+				FROM quiz_tags qt
+				JOIN tags t ON t.tag_id = qt.tag_id
+				WHERE t.name IN `+tagNames+`
+				GROUP BY qt.quiz_id
+				HAVING COUNT(*) = (SELECT COUNT(DISTINCT slug) FROM `+tagNames+` AS requested(slug))
+			)`, tags)
+		case "any":
+			f.add(`EXISTS (
+				SELECT 1
+				FROM quiz_tags qt
+				JOIN tags t ON t.tag_id = qt.tag_id
+				WHERE qt.quiz_id = q.quiz_id AND t.name IN `+tagNames+`
+			)`, tags)
+		default:
+			errs = append(errs, Field_Error{Field: "tag_mode", Message: "tag_mode must be all or any"})
+		}
+	}
+	return f, errs
+}
+
+// quizListPage reads sort, order, limit and cursor, reporting every bad one.
+func quizListPage(c *fiber.Ctx) (sort string, desc bool, limit int, cursor *quizCursor, errs []Field_Error) {
+	sort = c.Query("sort", "created_at")
+	sortBy, ok := quizSorts[sort]
+	if !ok {
+		errs = append(errs, Field_Error{Field: "sort", Message: "sort must be one of created_at, title or popularity"})
+	}
+	desc = sortBy.desc
+	switch c.Query("order") {
+	case "":
+	case "asc":
+		desc = false
+	case "desc":
+		desc = true
+	default:
+		errs = append(errs, Field_Error{Field: "order", Message: "order must be asc or desc"})
+	}
+
+	limit = defaultPageSize
+	if limitStr := c.Query("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 || n > maxPageSize {
+			errs = append(errs, Field_Error{Field: "limit", Message: fmt.Sprintf("limit must be between 1 and %d", maxPageSize)})
+		}
+		limit = n
+	}
+
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cur, err := decodeQuizCursor(cursorStr)
+		if err != nil {
+			errs = append(errs, Field_Error{Field: "cursor", Message: "invalid cursor"})
+		} else if cur.Sort != sort || cur.Desc != desc {
+			errs = append(errs, Field_Error{Field: "cursor", Message: "cursor was made for a different sort or order"})
+		} else {
+			cursor = &cur
+		}
+	}
+	return sort, desc, limit, cursor, errs
+}
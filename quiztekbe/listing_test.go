@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestQuizCursorRoundTrip(t *testing.T) {
+	cursors := []quizCursor{
+		{Sort: "created_at", Desc: true, Key: "2024-05-01T10:00:00Z", After: uuid.New()},
+		{Sort: "title", Key: "Über quiz & friends / 1", After: uuid.New()},
+		{Sort: "popularity", Desc: true, Key: "42", After: uuid.New()},
+		{Sort: "title", Key: "", After: uuid.New()},
+	}
+	for _, cur := range cursors {
+		t.Run(cur.Sort, func(t *testing.T) {
+			encoded := cur.encode()
+			if _, err := base64.RawURLEncoding.DecodeString(encoded); err != nil {
+				t.Fatalf("cursor %q is not URL safe base64: %v", encoded, err)
+			}
+			got, err := decodeQuizCursor(encoded)
+			if err != nil {
+				t.Fatalf("decoding %q: %v", encoded, err)
+			}
+			if got != cur {
+				t.Errorf("got %+v, want %+v", got, cur)
+			}
+		})
+	}
+}
+
+func TestDecodeQuizCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"title"}`))},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("title,42"))},
+		{"bad id", base64.RawURLEncoding.EncodeToString([]byte(`{"s":"title","id":"nope"}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cur, err := decodeQuizCursor(tt.input); err == nil {
+				t.Errorf("decoded %q as %+v, want an error", tt.input, cur)
+			}
+		})
+	}
+}
+
+func TestQuizListPage(t *testing.T) {
+	titleCursor := quizCursor{Sort: "title", Key: "a", After: uuid.New()}.encode()
+
+	tests := []struct {
+		name       string
+		query      string
+		wantSort   string
+		wantDesc   bool
+		wantLimit  int
+		wantCursor bool
+		wantFields []string
+	}{
+		{"defaults", "", "created_at", true, defaultPageSize, false, nil},
+		{"title sorts ascending", "sort=title", "title", false, defaultPageSize, false, nil},
+		{"explicit order", "sort=popularity&order=asc&limit=5", "popularity", false, 5, false, nil},
+		{"matching cursor", "sort=title&cursor=" + titleCursor, "title", false, defaultPageSize, true, nil},
+		{"cursor for another order", "sort=title&order=desc&cursor=" + titleCursor, "title", true, defaultPageSize, false, []string{"cursor"}},
+		{"garbage cursor", "cursor=%25%25", "created_at", true, defaultPageSize, false, []string{"cursor"}},
+		{"everything wrong", "sort=votes&order=up&limit=1000", "votes", false, 1000, false, []string{"sort", "order", "limit"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				sort, desc, limit, cursor, errs := quizListPage(c)
+				var fields []string
+				for _, e := range errs {
+					fields = append(fields, e.Field)
+				}
+				if len(fields) > 0 || len(tt.wantFields) > 0 {
+					if !reflect.DeepEqual(fields, tt.wantFields) {
+						t.Errorf("errors on %v, want %v", fields, tt.wantFields)
+					}
+					return nil
+				}
+				if sort != tt.wantSort || desc != tt.wantDesc || limit != tt.wantLimit || (cursor != nil) != tt.wantCursor {
+					t.Errorf("got sort %s desc %v limit %d cursor %v", sort, desc, limit, cursor)
+				}
+				return nil
+			})
+			if _, err := app.Test(httptest.NewRequest("GET", "/?"+tt.query, nil)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
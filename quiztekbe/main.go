@@ -3,53 +3,151 @@
 // @description     Backend for Quiztek
 // @host            localhost:3001
 // @BasePath        /
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
 package main
 
 import (
+	"context"
 	_ "github.com/DaffaI06/QuiztekBE/docs"
 	"github.com/gofiber/adaptor/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/recover"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	if err := connectToDb(); err != nil {
+	if err := run(); err != nil {
 		log.Fatal(err)
 	}
+}
+
+// run is main without the log.Fatal, so the deferred cleanup happens on every way out
+func run() error {
+	var err error
+	if config, err = loadConfig(); err != nil {
+		return err
+	}
+
+	if err := connectToDb(); err != nil {
+		return err
+	}
 	defer db.Close()
 
-	app := fiber.New()
+	// `app migrate ...` only touches the schema, it doesn't start the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		return runMigrateCommand(os.Args[2:])
+	}
+
+	// on by default so `docker compose up` gets a working schema, turn off when migrations
+	// are run as their own deploy step
+	if config.AutoMigrate {
+		if _, err := migrateUp(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	// SIGINT from a terminal, SIGTERM from docker stop
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go sweepExpiredAttempts(ctx, sweepInterval)
+
+	app := fiber.New(fiber.Config{
+		BodyLimit:    config.BodyLimit,
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+		IdleTimeout:  config.IdleTimeout,
+		ErrorHandler: errorHandler,
+	})
+
+	// a panicking handler becomes a 500 instead of taking the whole server down
+	app.Use(recover.New())
 
-	// Enable CORS
-	// AllowOrigin is set to all, change when prod
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
+		AllowOrigins: config.CORSOrigins,
 		AllowMethods: "GET,POST,PUT,PATCH,DELETE",
 		AllowHeaders: "Content-Type, Authorization",
+		// so browsers can see which questions a QTI export left out
+		ExposeHeaders: "X-QTI-Skipped",
 	}))
 
 	app.Get("/swagger/*", adaptor.HTTPHandler(httpSwagger.WrapHandler))
 
+	// probes stay ahead of Authenticate, so /healthz never waits on a session lookup
+	app.Get("/healthz", GetHealthz)
+	app.Get("/readyz", GetReadyz)
+
+	// every route after this sees the logged in user (if any) through currentUser
+	app.Use(Authenticate)
+
+	app.Post("/auth/register", PostRegister)
+	app.Post("/auth/login", PostLogin)
+	app.Post("/auth/logout", RequireAuth, PostLogout)
+	app.Get("/auth/me", RequireAuth, GetMe)
+
 	app.Get("/quiz", GetQuizzes)
+	app.Get("/quiz/search", SearchQuizzes) // before /quiz/:id, which would match it too
 	app.Get("/quiz/:id", GetQuiz)
-	app.Post("/quiz/create", PostQuiz)
-	app.Patch("/quiz/edit/:id", PatchQuiz)
-	app.Delete("/quiz/delete/:id", DeleteQuiz)
+	app.Post("/quiz/create", RequireAuth, PostQuiz)
+	app.Patch("/quiz/edit/:id", RequireAuth, PatchQuiz)
+	app.Delete("/quiz/delete/:id", RequireAuth, DeleteQuiz)
+	app.Post("/quiz/fork/:id", RequireAuth, ForkQuiz)
+	app.Get("/quiz/export/:id", ExportQuiz)
+	app.Post("/quiz/import", RequireAuth, ImportQuiz)
+	app.Get("/quiz/export/qti/:id", ExportQuizQTI)
+	app.Post("/quiz/import/qti", RequireAuth, ImportQuizQTI)
+
+	app.Get("/quiz/collaborator/:id", RequireAuth, GetCollaborators)
+	app.Post("/quiz/collaborator/:id", RequireAuth, PostCollaborator)
+	app.Delete("/quiz/collaborator/:id/:email", RequireAuth, DeleteCollaborator)
+	app.Put("/quiz/owner/:id", RequireAuth, PutQuizOwner)
+
+	app.Get("/quiz/tag/:id", GetQuizTags)
+	app.Post("/quiz/tag/:id", RequireAuth, PostQuizTags)
+	app.Delete("/quiz/tag/:id/:tag", RequireAuth, DeleteQuizTag)
+	app.Get("/tag", GetTagCloud)
+
+	app.Get("/category", GetCategories)
+	app.Get("/category/:id", GetCategory)
+	app.Post("/category/create", RequireAuth, PostCategory)
+	app.Patch("/category/edit/:id", RequireAuth, PatchCategory)
+	app.Delete("/category/delete/:id", RequireAuth, DeleteCategory)
 
 	app.Get("/quiz/question/:id", GetQuestionsByQuizId)
+	app.Put("/quiz/reorder/:id", RequireAuth, PutQuestionOrder)
 	app.Get("/question/:id", GetQuestion)
-	app.Post("/question/create/:id", PostQuestionByQuizId)
-	app.Patch("/question/edit/:id", PatchQuestion)
-	app.Delete("/question/delete/:id", DeleteQuestion)
+	app.Post("/question/create/:id", RequireAuth, PostQuestionByQuizId)
+	app.Post("/question/batch/:id", RequireAuth, PostQuestionBatchByQuizId)
+	app.Post("/question/import/:id", RequireAuth, ImportQuestions)
+	app.Patch("/question/edit/:id", RequireAuth, PatchQuestion)
+	app.Delete("/question/delete/:id", RequireAuth, DeleteQuestion)
 
 	app.Post("/submission/attempt/:id", PostAttemptByQuizId)
 	app.Put("/submission/answer/:id", PutAnswerByAttemptId)
 	app.Get("/submission/latest/:id", GetLatestSubmissions)
+	app.Get("/submission/result/:attemptid", GetAttemptResult)
 	app.Get("/submission/:attemptid/:questionid", GetAnswer)
 	app.Put("/submission/attempt/complete/:attemptid", CompleteAttempt)
 
-	log.Fatal(app.Listen(":8080"))
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(config.ListenAddr)
+	}()
+
+	select {
+	case err := <-listenErr:
+		return err
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process straight away
 
+	log.Printf("shutting down, giving requests in flight up to %s", config.ShutdownTimeout)
+	return app.ShutdownWithTimeout(config.ShutdownTimeout)
 }
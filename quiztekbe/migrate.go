@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrations/NNNN_name.up.sql and NNNN_name.down.sql, applied in version order. Every up
+// migration is written to be a no-op on a database that already has its changes, so volumes
+// created from the old init.sql can be brought under the runner without wiping them.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// any constant works, as long as every replica takes the same one
+const migrationLockKey = 7305118
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type migration struct {
+	version int64
+	name    string
+	up      string
+	down    string // empty when the migration can't be rolled back
+}
+
+type migrationState struct {
+	migration
+	appliedAt *time.Time // nil while pending
+}
+
+func loadMigrations() ([]migration, error) {
+	files, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*migration{}
+	for _, file := range files {
+		match := migrationFileName.FindStringSubmatch(file.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: name must look like 0001_name.up.sql", file.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", file.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: match[2]}
+			byVersion[version] = m
+		} else if m.name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.name, match[2])
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join("migrations", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up migration", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// appliedMigrations maps applied versions to when they were applied. A database the runner
+// has never touched has no schema_migrations table yet, which just means nothing is applied.
+func appliedMigrations(ctx context.Context, q dbtx) (map[int64]time.Time, error) {
+	rows, err := q.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "42P01" { // undefined_table
+			return map[int64]time.Time{}, nil
+		}
+		return nil, err
+	}
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+func migrationStatus(ctx context.Context, q dbtx) ([]migrationState, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]migrationState, len(migrations))
+	for i, m := range migrations {
+		states[i].migration = m
+		if at, ok := applied[m.version]; ok {
+			states[i].appliedAt = &at
+		}
+	}
+	return states, nil
+}
+
+// pendingMigrations lists the embedded migrations this database hasn't applied yet
+func pendingMigrations(ctx context.Context, q dbtx) ([]migration, error) {
+	states, err := migrationStatus(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []migration
+	for _, s := range states {
+		if s.appliedAt == nil {
+			pending = append(pending, s.migration)
+		}
+	}
+	return pending, nil
+}
+
+// withMigrationLock runs fn on one connection holding the migration advisory lock, so when
+// several replicas start at once one migrates and the rest wait, then find nothing to do.
+func withMigrationLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return err
+	}
+	// the lock belongs to the session, not a transaction, so it has to be given back before
+	// the connection goes back to the pool. Background so a cancelled ctx still unlocks.
+	defer func() {
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			log.Println("migrate: releasing lock:", err)
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// runMigration runs one migration file and records it in the same transaction, so a failed
+// migration leaves neither its changes nor its schema_migrations row behind
+func runMigration(ctx context.Context, conn *pgxpool.Conn, m migration, up bool) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	sql, record := m.up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+	if !up {
+		sql, record = m.down, `DELETE FROM schema_migrations WHERE version = $1 AND name = $2`
+	}
+
+	// no arguments, so pgx sends it as a simple query and a file can hold several statements
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("migration %d_%s: %w", m.version, m.name, err)
+	}
+	if _, err := tx.Exec(ctx, record, m.version, m.name); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// migrateUp applies every pending migration and returns how many it applied
+func migrateUp(ctx context.Context) (int, error) {
+	count := 0
+	err := withMigrationLock(ctx, func(conn *pgxpool.Conn) error {
+		// checked again under the lock, another replica may have just applied them
+		pending, err := pendingMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range pending {
+			if err := runMigration(ctx, conn, m, true); err != nil {
+				return err
+			}
+			log.Printf("migrate: applied %d_%s", m.version, m.name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// migrateDown rolls back the latest steps applied migrations and returns how many it rolled back
+func migrateDown(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := withMigrationLock(ctx, func(conn *pgxpool.Conn) error {
+		states, err := migrationStatus(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(states) - 1; i >= 0 && count < steps; i-- {
+			m := states[i]
+			if m.appliedAt == nil {
+				continue
+			}
+			if m.down == "" {
+				return fmt.Errorf("migration %d_%s has no down migration", m.version, m.name)
+			}
+			if err := runMigration(ctx, conn, m.migration, false); err != nil {
+				return err
+			}
+			log.Printf("migrate: rolled back %d_%s", m.version, m.name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// runMigrateCommand handles `app migrate [up | down [n] | status]`
+func runMigrateCommand(args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		count, err := migrateUp(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("migrate: %d migrations applied", count)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("migrate down: steps must be a positive number, got %q", args[1])
+			}
+			steps = n
+		}
+		count, err := migrateDown(ctx, steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("migrate: %d migrations rolled back", count)
+	case "status":
+		states, err := migrationStatus(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			applied := "pending"
+			if s.appliedAt != nil {
+				applied = s.appliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.version, s.name, applied)
+		}
+	default:
+		return fmt.Errorf("migrate: unknown command %q, want up, down [n] or status", command)
+	}
+	return nil
+}
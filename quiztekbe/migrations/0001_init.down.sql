@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS submission_answers;
+DROP TABLE IF EXISTS submission_attempts;
+DROP TABLE IF EXISTS questions;
+DROP TABLE IF EXISTS quizzes;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS quiz_collaborators;
+ALTER TABLE quizzes DROP CONSTRAINT IF EXISTS quizzes_creator_email_fkey;
+DROP TABLE IF EXISTS sessions;
+ALTER TABLE users DROP COLUMN IF EXISTS created_at;
+ALTER TABLE users DROP COLUMN IF EXISTS password_hash;
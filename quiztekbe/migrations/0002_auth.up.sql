@@ -0,0 +1,32 @@
+-- users from before there were passwords get an empty hash, which never matches, so
+-- they have to register again
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_hash TEXT NOT NULL DEFAULT ''; -- bcrypt
+ALTER TABLE users ALTER COLUMN password_hash DROP DEFAULT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ DEFAULT now();
+
+CREATE TABLE IF NOT EXISTS sessions (
+    token_hash TEXT PRIMARY KEY, -- sha256 of the bearer token, the raw token is never stored
+    email TEXT NOT NULL REFERENCES users(email) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- creator_email used to be free text, often "", so only real users survive the foreign key.
+-- NULL is why the handlers coalesce it.
+UPDATE quizzes SET creator_email = NULL
+WHERE creator_email IS NOT NULL AND creator_email NOT IN (SELECT email FROM users);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'quizzes_creator_email_fkey') THEN
+        ALTER TABLE quizzes ADD CONSTRAINT quizzes_creator_email_fkey
+            FOREIGN KEY (creator_email) REFERENCES users(email) ON DELETE SET NULL;
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS quiz_collaborators (
+    quiz_id UUID REFERENCES quizzes(quiz_id) ON DELETE CASCADE,
+    email TEXT REFERENCES users(email) ON DELETE CASCADE,
+    added_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (quiz_id, email) -- collaborators can edit, only the creator can delete or share
+);
@@ -0,0 +1,7 @@
+ALTER TABLE questions DROP CONSTRAINT IF EXISTS unique_quiz_position;
+ALTER TABLE questions
+    DROP COLUMN IF EXISTS penalty,
+    DROP COLUMN IF EXISTS points,
+    DROP COLUMN IF EXISTS scoring_mode,
+    DROP COLUMN IF EXISTS correct_choices,
+    DROP COLUMN IF EXISTS fib_options;
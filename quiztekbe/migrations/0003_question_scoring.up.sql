@@ -0,0 +1,25 @@
+-- type gains 'ms' (multiple select)
+ALTER TABLE questions
+    ADD COLUMN IF NOT EXISTS fib_options JSONB DEFAULT NULL, -- matching rules for 'fib', see Fib_Options in model.go
+    ADD COLUMN IF NOT EXISTS correct_choices INT[] DEFAULT NULL, -- For multiple select: every correct index into choices
+    ADD COLUMN IF NOT EXISTS scoring_mode VARCHAR(20) DEFAULT NULL, -- For multiple select: 'all_or_nothing' or 'partial'
+    ADD COLUMN IF NOT EXISTS points DOUBLE PRECISION NOT NULL DEFAULT 1 CHECK (points >= 0),
+    ADD COLUMN IF NOT EXISTS penalty DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (penalty >= 0); -- taken off for a wrong 'tf'/'mc' answer, unanswered costs nothing
+
+-- positions could collide before they were locked, close them up to 1..n per quiz first
+UPDATE questions q
+SET position = ordered.position
+FROM (
+    SELECT question_id, ROW_NUMBER() OVER (PARTITION BY quiz_id ORDER BY position, question_id) AS position
+    FROM questions
+) ordered
+WHERE q.question_id = ordered.question_id AND q.position <> ordered.position;
+
+-- deferrable so shifting a run of positions is checked once the statement is done, not row by row
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'unique_quiz_position') THEN
+        ALTER TABLE questions ADD CONSTRAINT unique_quiz_position
+            UNIQUE (quiz_id, position) DEFERRABLE INITIALLY IMMEDIATE;
+    END IF;
+END $$;
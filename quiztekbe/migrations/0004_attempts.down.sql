@@ -0,0 +1,15 @@
+ALTER TABLE submission_answers
+    DROP COLUMN IF EXISTS points,
+    DROP COLUMN IF EXISTS credit,
+    DROP COLUMN IF EXISTS selected_choices;
+
+DROP INDEX IF EXISTS submission_attempts_open_deadline;
+
+ALTER TABLE submission_attempts
+    DROP COLUMN IF EXISTS total,
+    DROP COLUMN IF EXISTS score,
+    DROP COLUMN IF EXISTS deadline,
+    DROP COLUMN IF EXISTS started_at,
+    DROP COLUMN IF EXISTS status;
+
+ALTER TABLE quizzes DROP COLUMN IF EXISTS time_limit_seconds;
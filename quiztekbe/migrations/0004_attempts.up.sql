@@ -0,0 +1,25 @@
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS time_limit_seconds INT DEFAULT NULL CHECK (time_limit_seconds > 0); -- NULL means untimed
+
+ALTER TABLE submission_attempts
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'in_progress' CHECK (status IN ('in_progress', 'submitted', 'graded')),
+    ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS deadline TIMESTAMPTZ DEFAULT NULL, -- started_at + the quiz time limit, NULL when untimed
+    ADD COLUMN IF NOT EXISTS score DOUBLE PRECISION DEFAULT NULL, -- filled in by the grader when the attempt is completed
+    ADD COLUMN IF NOT EXISTS total DOUBLE PRECISION DEFAULT NULL; -- sum of question points at grading time
+
+-- score and total started out as INT
+ALTER TABLE submission_attempts
+    ALTER COLUMN score TYPE DOUBLE PRECISION,
+    ALTER COLUMN total TYPE DOUBLE PRECISION;
+
+-- attempts completed before there were states are closed, but were never graded
+UPDATE submission_attempts SET status = 'submitted'
+WHERE completed_at IS NOT NULL AND status = 'in_progress';
+
+-- lets the sweeper find expired attempts without scanning finished ones
+CREATE INDEX IF NOT EXISTS submission_attempts_open_deadline ON submission_attempts (deadline) WHERE status = 'in_progress';
+
+ALTER TABLE submission_answers
+    ADD COLUMN IF NOT EXISTS selected_choices INT[] DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS credit DOUBLE PRECISION DEFAULT NULL, -- 0 to 1, set by the grader
+    ADD COLUMN IF NOT EXISTS points DOUBLE PRECISION DEFAULT NULL; -- credit * question points, minus the penalty when wrong
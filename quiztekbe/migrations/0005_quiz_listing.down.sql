@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS questions_search;
+ALTER TABLE questions DROP COLUMN IF EXISTS search_vector;
+DROP FUNCTION IF EXISTS question_search_vector(TEXT, TEXT[]);
+
+DROP INDEX IF EXISTS quizzes_search;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS search_vector;
+
+DROP INDEX IF EXISTS submission_attempts_quiz;
+DROP INDEX IF EXISTS quizzes_created_at;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS source_quiz_id;
@@ -0,0 +1,26 @@
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS source_quiz_id UUID REFERENCES quizzes(quiz_id) ON DELETE SET NULL; -- the quiz this one was forked from, if any
+
+-- default order of GET /quiz
+CREATE INDEX IF NOT EXISTS quizzes_created_at ON quizzes (created_at, quiz_id);
+
+-- popularity sort on GET /quiz counts attempts per quiz
+CREATE INDEX IF NOT EXISTS submission_attempts_quiz ON submission_attempts (quiz_id);
+
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(category, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS quizzes_search ON quizzes USING GIN (search_vector);
+
+-- array_to_string is only STABLE, so generated columns need it wrapped. Safe here since
+-- text[] to text doesn't depend on any setting.
+CREATE OR REPLACE FUNCTION question_search_vector(message TEXT, choices TEXT[]) RETURNS TSVECTOR
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT setweight(to_tsvector('english', COALESCE(message, '')), 'C') ||
+           setweight(to_tsvector('english', COALESCE(array_to_string(choices, ' '), '')), 'D')
+$$;
+
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (question_search_vector(message, choices)) STORED;
+
+CREATE INDEX IF NOT EXISTS questions_search ON questions USING GIN (search_vector);
@@ -0,0 +1,5 @@
+-- quizzes keep their category names, only the links go
+DROP INDEX IF EXISTS quizzes_category;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS categories;
+DROP FUNCTION IF EXISTS category_slug(TEXT);
@@ -0,0 +1,39 @@
+-- the one place a category name turns into a slug, so "Math ", "math" and "MATH" are one category
+CREATE OR REPLACE FUNCTION category_slug(name TEXT) RETURNS TEXT
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT trim(BOTH '-' FROM regexp_replace(lower(trim(name)), '[^a-z0-9]+', '-', 'g'))
+$$;
+
+CREATE TABLE IF NOT EXISTS categories (
+    category_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug TEXT NOT NULL,
+    name TEXT NOT NULL,
+    parent_id UUID REFERENCES categories(category_id), -- NULL for top level, a category with children can't be deleted
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT categories_slug_key UNIQUE (slug),
+    CONSTRAINT categories_slug_valid CHECK (slug <> '' AND slug = category_slug(slug)),
+    CONSTRAINT categories_name_valid CHECK (trim(name) <> '')
+);
+
+-- quizzes.category stays as the name of category_id, kept in step by the handlers so
+-- listing and search don't need the join
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS category_id UUID REFERENCES categories(category_id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS quizzes_category ON quizzes (category_id);
+
+-- one category per slug, named after its most used spelling
+INSERT INTO categories (slug, name)
+SELECT DISTINCT ON (slug) slug, name
+FROM (
+    SELECT category_slug(category) AS slug, trim(category) AS name, COUNT(*) AS uses
+    FROM quizzes
+    WHERE category_id IS NULL AND category IS NOT NULL
+    GROUP BY 1, 2
+) spellings
+WHERE slug <> ''
+ORDER BY slug, uses DESC, name
+ON CONFLICT (slug) DO NOTHING;
+
+UPDATE quizzes q
+SET category_id = c.category_id, category = c.name
+FROM categories c
+WHERE q.category_id IS NULL AND c.slug = category_slug(q.category);
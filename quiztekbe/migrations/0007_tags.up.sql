@@ -0,0 +1,17 @@
+-- tags are stored already normalized, with the same rules as category slugs
+CREATE TABLE IF NOT EXISTS tags (
+    tag_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    CONSTRAINT tags_name_key UNIQUE (name),
+    CONSTRAINT tags_name_valid CHECK (name <> '' AND name = category_slug(name))
+);
+
+CREATE TABLE IF NOT EXISTS quiz_tags (
+    quiz_id UUID REFERENCES quizzes(quiz_id) ON DELETE CASCADE,
+    tag_id UUID REFERENCES tags(tag_id) ON DELETE CASCADE,
+    added_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (quiz_id, tag_id)
+);
+
+-- the primary key covers lookups by quiz, this one filtering and counting by tag
+CREATE INDEX IF NOT EXISTS quiz_tags_tag ON quiz_tags (tag_id);
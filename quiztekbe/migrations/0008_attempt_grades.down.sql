@@ -0,0 +1,12 @@
+ALTER TABLE submission_answers
+    ADD COLUMN IF NOT EXISTS credit DOUBLE PRECISION DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS points DOUBLE PRECISION DEFAULT NULL;
+
+UPDATE submission_answers ans
+SET credit = g.credit, points = g.points
+FROM attempt_grades g
+WHERE g.attempt_id = ans.attempt_id
+  AND g.answer IS NOT NULL
+  AND (g.question ->> 'question_id')::uuid = ans.question_id;
+
+DROP TABLE IF EXISTS attempt_grades;
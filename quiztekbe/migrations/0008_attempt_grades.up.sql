@@ -0,0 +1,32 @@
+-- one row per question of a graded attempt, written by the grader. The question and answer are
+-- copied in as they were graded, so editing or deleting a question later can't change a result.
+CREATE TABLE IF NOT EXISTS attempt_grades (
+    attempt_id UUID NOT NULL REFERENCES submission_attempts(attempt_id) ON DELETE CASCADE,
+    position INT NOT NULL, -- the question's position when graded
+    question JSONB NOT NULL, -- see Question in model.go, correct answer included
+    answer JSONB DEFAULT NULL, -- see Submission_answer in model.go, NULL when unanswered
+    credit DOUBLE PRECISION NOT NULL, -- 0 to 1
+    points DOUBLE PRECISION NOT NULL, -- earned, negative when penalised
+    max_points DOUBLE PRECISION NOT NULL, -- the question's points at grading time
+    PRIMARY KEY (attempt_id, position)
+);
+
+-- attempts graded before this table get their grades from submission_answers and the questions
+-- as they are now, which is what their result page showed until now. Read through to_jsonb so
+-- this still runs once the columns below are gone.
+INSERT INTO attempt_grades (attempt_id, position, question, answer, credit, points, max_points)
+SELECT sa.attempt_id, q.position, to_jsonb(q),
+       CASE WHEN ans.attempt_id IS NOT NULL THEN to_jsonb(ans) - 'credit' - 'points' END,
+       COALESCE((to_jsonb(ans) ->> 'credit')::float8, 0),
+       COALESCE((to_jsonb(ans) ->> 'points')::float8, 0),
+       q.points
+FROM submission_attempts sa
+JOIN questions q ON q.quiz_id = sa.quiz_id
+LEFT JOIN submission_answers ans ON ans.attempt_id = sa.attempt_id AND ans.question_id = q.question_id
+WHERE sa.status = 'graded'
+  AND NOT EXISTS (SELECT 1 FROM attempt_grades g WHERE g.attempt_id = sa.attempt_id);
+
+-- attempt_grades replaces these
+ALTER TABLE submission_answers
+    DROP COLUMN IF EXISTS credit,
+    DROP COLUMN IF EXISTS points;
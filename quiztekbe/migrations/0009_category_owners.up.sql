@@ -0,0 +1,3 @@
+-- only whoever made a category, or an admin, can rename, move or delete it. Categories from
+-- before this, and the ones quizzes used to create on the fly, are left to the admins.
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS created_by TEXT REFERENCES users(email) ON DELETE SET NULL;
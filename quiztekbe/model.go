@@ -6,63 +6,211 @@ import (
 )
 
 type User struct {
-	Email string `json:"email"`
+	Email      string    `json:"email"`
+	Created_at time.Time `json:"created_at"`
+	Is_admin   bool      `json:"is_admin"` // listed in ADMIN_EMAILS
+}
+
+type User_Credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
 }
 
 type Quiz struct {
-	Quiz_id       uuid.UUID `json:"id"`
-	Title         string    `json:"title"`
-	Category      string    `json:"category"`
-	Creator_email string    `json:"creator_email"`
-	Created_at    time.Time `json:"created_at"`
+	Quiz_id       uuid.UUID  `json:"id"`
+	Title         string     `json:"title"`
+	Category      string     `json:"category"` // name of the category
+	Category_id   *uuid.UUID `json:"category_id"`
+	Creator_email string     `json:"creator_email"`
+	Created_at    time.Time  `json:"created_at"`
+	Time_limit    *int       `json:"time_limit"` // seconds, nil means untimed
 }
 
 type Quiz_Detail struct {
-	Quiz_id       uuid.UUID `json:"id"`
-	Title         string    `json:"title"`
-	Category      string    `json:"category"`
-	Creator_email string    `json:"creator_email"`
-	Created_at    time.Time `json:"created_at"`
+	Quiz_id        uuid.UUID  `json:"id"`
+	Title          string     `json:"title"`
+	Category       string     `json:"category"`
+	Category_id    *uuid.UUID `json:"category_id"`
+	Creator_email  string     `json:"creator_email"`
+	Created_at     time.Time  `json:"created_at"`
+	Time_limit     *int       `json:"time_limit"`     // seconds, nil means untimed
+	Source_quiz_id *uuid.UUID `json:"source_quiz_id"` // set on forks
+	Tags           []string   `json:"tags"`
 }
 
 type Quiz_Post struct {
-	Title    string `json:"title"`
-	Category string `json:"category"`
-	// Creator_email string `json:"creator_email"`
-	// need to wait til auth is implemented, rn it defaults to '' email
-	// error and temp solution documented in console
+	Title       string     `json:"title"`
+	Category    string     `json:"category"`    // slug or id of an existing category, used when category_id is empty
+	Category_id *uuid.UUID `json:"category_id"` // takes precedence over category
+	Time_limit  *int       `json:"time_limit"`
+	// creator_email comes from the logged in user, not the body
 }
 
+// Quiz_Update changes only the fields that are sent.
 type Quiz_Update struct {
-	Title    string `json:"title"`
-	Category string `json:"category"`
+	Title       *string    `json:"title"`
+	Category    *string    `json:"category"`    // slug or id of an existing category, "" makes the quiz uncategorised
+	Category_id *uuid.UUID `json:"category_id"` // takes precedence over category
+	Time_limit  *int       `json:"time_limit"`  // 0 removes the limit
+}
+
+type Category struct {
+	Category_id      uuid.UUID  `json:"id"`
+	Slug             string     `json:"slug"`
+	Name             string     `json:"name"`
+	Parent_id        *uuid.UUID `json:"parent_id"`
+	Created_by       *string    `json:"created_by"` // nil for categories only admins can change
+	Created_at       time.Time  `json:"created_at"`
+	Quiz_count       int        `json:"quiz_count"`       // quizzes directly in this category
+	Total_quiz_count int        `json:"total_quiz_count"` // including every subcategory
+}
+
+type Category_Post struct {
+	Name      string     `json:"name"`
+	Slug      string     `json:"slug"` // derived from name when empty
+	Parent_id *uuid.UUID `json:"parent_id"`
+}
+
+// Category_Patch changes only the fields that are sent.
+type Category_Patch struct {
+	Name      *string `json:"name"`
+	Slug      *string `json:"slug"`      // "" derives it from the name again
+	Parent_id *string `json:"parent_id"` // a category id, or "" to move it to the top level
+}
+
+type Quiz_Tags_Post struct {
+	Tags []string `json:"tags"`
+}
+
+type Health_Status struct {
+	Status string `json:"status"` // "ok" or "unavailable"
+}
+
+type Tag_Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type Quiz_Collaborator struct {
+	Email    string    `json:"email"`
+	Added_at time.Time `json:"added_at"`
+}
+
+type Collaborator_Post struct {
+	Email string `json:"email"`
+}
+
+type Owner_Put struct {
+	Email string `json:"email"`
 }
 
 type Question struct {
-	Quiz_id         uuid.UUID `json:"quiz_id"`
-	Question_id     uuid.UUID `json:"question_id"`
-	Position        int       `json:"position"`
-	Type            string    `json:"type"` // 'tf', 'mc', 'fib'
-	Message         string    `json:"message"`
-	Choices         []string  `json:"choices"`
-	Answer_tf       *bool     `json:"answer_tf"`
-	Correct_choice  *int      `json:"correct_choice"`
-	Correct_answers []string  `json:"correct_answers"`
+	Quiz_id         uuid.UUID    `json:"quiz_id"`
+	Question_id     uuid.UUID    `json:"question_id"`
+	Position        int          `json:"position"`
+	Type            string       `json:"type"` // 'tf', 'mc', 'ms', 'fib'
+	Message         string       `json:"message"`
+	Choices         []string     `json:"choices"`
+	Answer_tf       *bool        `json:"answer_tf"`
+	Correct_choice  *int         `json:"correct_choice"`
+	Correct_answers []string     `json:"correct_answers"`
+	Fib_options     *Fib_Options `json:"fib_options"`
+	Correct_choices []int        `json:"correct_choices"` // 'ms' only
+	Scoring_mode    string       `json:"scoring_mode"`    // 'ms' only: 'all_or_nothing' (default) or 'partial'
+	Points          float64      `json:"points"`
+	Penalty         float64      `json:"penalty"` // 'tf' and 'mc' only, taken off for a wrong answer
 }
 
 type Question_Update struct {
-	Type            string   `json:"type"`
-	Message         string   `json:"message"`
-	Choices         []string `json:"choices"`
-	Answer_tf       *bool    `json:"answer_tf"`
-	Correct_choice  *int     `json:"correct_choice"`
-	Correct_answers []string `json:"correct_answers"`
+	Type            string       `json:"type"`
+	Message         string       `json:"message"`
+	Choices         []string     `json:"choices"`
+	Answer_tf       *bool        `json:"answer_tf"`
+	Correct_choice  *int         `json:"correct_choice"`
+	Correct_answers []string     `json:"correct_answers"`
+	Fib_options     *Fib_Options `json:"fib_options"`
+	Correct_choices []int        `json:"correct_choices"`
+	Scoring_mode    string       `json:"scoring_mode"`
+	Points          *float64     `json:"points"`  // nil keeps the current value
+	Penalty         *float64     `json:"penalty"` // nil keeps the current value
+}
+
+// Question_Reorder is either the quiz's full new order, or a single move between
+// two 1-based positions.
+type Question_Reorder struct {
+	Question_ids []uuid.UUID `json:"question_ids"`
+	From         *int        `json:"from"`
+	To           *int        `json:"to"`
+}
+
+// Fib_Options loosens how 'fib' answers are matched, nil means exact matching.
+// Stored as JSONB on questions.fib_options.
+type Fib_Options struct {
+	Case_insensitive  bool       `json:"case_insensitive"`
+	Trim_whitespace   bool       `json:"trim_whitespace"`
+	Alternatives      [][]string `json:"alternatives"`      // extra accepted answers, indexed like correct_answers
+	Numeric_tolerance *float64   `json:"numeric_tolerance"` // numbers within this distance of the answer count
+	Regex             bool       `json:"regex"`             // accepted answers are regular expressions matched against the whole blank
 }
 
 type Submission_answer struct {
-	Attempt_id      uuid.UUID `json:"attempt_id"`
-	Question_id     uuid.UUID `json:"question_id"`
-	Answer_tf       *bool     `json:"answer_tf"`
-	Correct_choice  *int      `json:"correct_choice"`
-	Correct_answers []string  `json:"correct_answers"`
+	Attempt_id       uuid.UUID `json:"attempt_id"`
+	Question_id      uuid.UUID `json:"question_id"`
+	Answer_tf        *bool     `json:"answer_tf"`
+	Correct_choice   *int      `json:"correct_choice"`
+	Correct_answers  []string  `json:"correct_answers"`
+	Selected_choices []int     `json:"selected_choices"` // 'ms' only
+}
+
+// Submission_Result is one graded attempt in GET /submission/latest.
+type Submission_Result struct {
+	Attempt_id   uuid.UUID `json:"attempt_id"`
+	Completed_at string    `json:"completed_at"` // formatted as "HH:MM DD Month YYYY"
+	Total        float64   `json:"total"`
+	Score        float64   `json:"score"`
+}
+
+type Attempt_Result struct {
+	Attempt_id   uuid.UUID         `json:"attempt_id"`
+	Quiz_id      uuid.UUID         `json:"quiz_id"`
+	Started_at   time.Time         `json:"started_at"`
+	Completed_at time.Time         `json:"completed_at"`
+	Score        float64           `json:"score"`
+	Total        float64           `json:"total"`
+	Questions    []Question_Result `json:"questions"`
+}
+
+type Question_Result struct {
+	Question   Question           `json:"question"`  // as graded, includes the correct answer
+	Submitted  *Submission_answer `json:"submitted"` // nil when left unanswered
+	Is_correct bool               `json:"is_correct"`
+	Points     float64            `json:"points"`     // earned on this question, negative when penalised
+	Max_points float64            `json:"max_points"` // what the question was worth when graded
+}
+
+// Quiz_Bundle is the portable export of a quiz. Ids, positions, owner and timestamps are
+// informational only, an import gets fresh ones and takes the question order from the list.
+type Quiz_Bundle struct {
+	Format      string     `json:"format"`  // always "quiztek.quiz"
+	Version     int        `json:"version"` // bumped on incompatible changes
+	Exported_at time.Time  `json:"exported_at"`
+	Quiz        Quiz       `json:"quiz"`
+	Questions   []Question `json:"questions"`
+}
+
+// Quiz_Page is one page of GET /quiz. Next_cursor is nil on the last page, Total counts
+// every quiz matching the filters, not just this page.
+type Quiz_Page struct {
+	Quizzes     []Quiz  `json:"quizzes"`
+	Next_cursor *string `json:"next_cursor"`
+	Total       int     `json:"total"`
+}
+
+// Quiz_Search_Result is a quiz matching a search. The highlights wrap matched words in
+// <mark> and are HTML escaped otherwise, so they can be rendered as is.
+type Quiz_Search_Result struct {
+	Quiz            Quiz    `json:"quiz"`
+	Rank            float64 `json:"rank"`
+	Title_highlight string  `json:"title_highlight"`
+	Snippet         *string `json:"snippet"` // best matching question, nil when only the title or category matched
 }
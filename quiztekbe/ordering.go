@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// lockQuizOrder takes a lock on the quiz row that every change to its question positions
+// goes through, so inserts, deletes and reorders on the same quiz run one at a time. It
+// returns the current number of questions, or pgx.ErrNoRows if the quiz doesn't exist.
+// FOR NO KEY UPDATE still lets attempts and answers reference the quiz meanwhile.
+func lockQuizOrder(ctx context.Context, q dbtx, quizID uuid.UUID) (int, error) {
+	var locked uuid.UUID
+	if err := q.QueryRow(ctx, "SELECT quiz_id FROM quizzes WHERE quiz_id = $1 FOR NO KEY UPDATE", quizID).Scan(&locked); err != nil {
+		return 0, err
+	}
+	var count int
+	err := q.QueryRow(ctx, "SELECT COUNT(*) FROM questions WHERE quiz_id = $1", quizID).Scan(&count)
+	return count, err
+}
+
+// shiftPositions moves every question at or after from by delta, to open or close a gap.
+func shiftPositions(ctx context.Context, q dbtx, quizID uuid.UUID, from int, delta int) error {
+	queryStr := "UPDATE questions SET position = position + $3 WHERE quiz_id = $1 AND position >= $2"
+	_, err := q.Exec(ctx, queryStr, quizID, from, delta)
+	return err
+}
+
+// quizQuestionIDs returns the quiz's question ids in position order.
+func quizQuestionIDs(ctx context.Context, q dbtx, quizID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.Query(ctx, "SELECT question_id FROM questions WHERE quiz_id = $1 ORDER BY position", quizID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// newQuestionOrder works out the order a Question_Reorder asks for, checking it against
+// the quiz's current questions.
+func newQuestionOrder(current []uuid.UUID, reorder Question_Reorder) ([]uuid.UUID, []Field_Error) {
+	if reorder.Question_ids != nil {
+		if reorder.From != nil || reorder.To != nil {
+			return nil, []Field_Error{{Field: "question_ids", Message: "send either question_ids or from/to, not both"}}
+		}
+		return reorder.Question_ids, sameQuestionSet(current, reorder.Question_ids)
+	}
+
+	if reorder.From == nil || reorder.To == nil {
+		return nil, []Field_Error{{Field: "question_ids", Message: "question_ids or both from and to are required"}}
+	}
+	var errs []Field_Error
+	for _, field := range []struct {
+		name string
+		pos  int
+	}{{"from", *reorder.From}, {"to", *reorder.To}} {
+		if field.pos < 1 || field.pos > len(current) {
+			errs = append(errs, Field_Error{Field: field.name, Message: fmt.Sprintf("position %d is out of range for %d questions", field.pos, len(current))})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	from, to := *reorder.From-1, *reorder.To-1
+	moved := current[from]
+	order := make([]uuid.UUID, 0, len(current))
+	order = append(order, current[:from]...)
+	order = append(order, current[from+1:]...)
+	order = append(order[:to], append([]uuid.UUID{moved}, order[to:]...)...)
+	return order, nil
+}
+
+// sameQuestionSet checks the new order lists every current question exactly once.
+func sameQuestionSet(current, order []uuid.UUID) []Field_Error {
+	var errs []Field_Error
+	inQuiz := make(map[uuid.UUID]bool, len(current))
+	for _, id := range current {
+		inQuiz[id] = true
+	}
+	seen := make(map[uuid.UUID]bool, len(order))
+	for i, id := range order {
+		field := fmt.Sprintf("question_ids[%d]", i)
+		switch {
+		case !inQuiz[id]:
+			errs = append(errs, Field_Error{Field: field, Message: fmt.Sprintf("question %s is not in this quiz", id)})
+		case seen[id]:
+			errs = append(errs, Field_Error{Field: field, Message: fmt.Sprintf("question %s is listed more than once", id)})
+		}
+		seen[id] = true
+	}
+	if len(errs) == 0 && len(order) != len(current) {
+		errs = append(errs, Field_Error{Field: "question_ids", Message: fmt.Sprintf("expected all %d questions of the quiz, got %d", len(current), len(order))})
+	}
+	return errs
+}
+
+// writeQuestionOrder sets position to each question's 1-based index in order.
+func writeQuestionOrder(ctx context.Context, q dbtx, quizID uuid.UUID, order []uuid.UUID) error {
+	queryStr := `
+		UPDATE questions q
+		SET position = new.position
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS new(question_id, position)
+		WHERE q.quiz_id = $1 AND q.question_id = new.question_id
+	`
+	_, err := q.Exec(ctx, queryStr, quizID, order)
+	return err
+}
+
+// PutQuestionOrder godoc
+// @Summary      Reorder a quiz's questions
+// @Description  Rewrite question positions in one transaction, either from the quiz's full new order of question IDs or by moving the question at position from to position to (both 1-based).
+// @Tags         quiz, question
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string            true  "Quiz ID"
+// @Param        body  body      Question_Reorder  true  "New order, or a single move"
+// @Success      200   {array}   string            "Question IDs in their new order"
+// @Failure      400   {object}  API_Error  "Invalid quiz ID or JSON payload"
+// @Failure      403   {object}  API_Error  "Not the creator or a collaborator"
+// @Failure      404   {object}  API_Error  "Quiz not found"
+// @Failure      422   {object}  API_Error  "Order doesn't match the quiz's questions"
+// @Failure      500   {object}  API_Error  "Internal server error"
+// @Router       /quiz/reorder/{id} [put]
+func PutQuestionOrder(c *fiber.Ctx) error {
+	quizIDStr := c.Params("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz ID")
+	}
+	if err := authorizeQuiz(c, quizID, roleCollaborator); err != nil {
+		return err
+	}
+
+	var reorder Question_Reorder
+	if err := c.BodyParser(&reorder); err != nil {
+		return badRequest("Cannot parse JSON")
+	}
+
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		return internalError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	if _, err := lockQuizOrder(context.Background(), tx, quizID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Quiz not found")
+		}
+		return internalError("Failed to lock quiz", err)
+	}
+
+	current, err := quizQuestionIDs(context.Background(), tx, quizID)
+	if err != nil {
+		return internalError("Failed to fetch questions", err)
+	}
+
+	order, fieldErrs := newQuestionOrder(current, reorder)
+	if len(fieldErrs) > 0 {
+		return validationFailed("Invalid question order", fieldErrs)
+	}
+
+	if err := writeQuestionOrder(context.Background(), tx, quizID, order); err != nil {
+		return internalError("Failed to update positions", err)
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return internalError("Failed to commit transaction", err)
+	}
+
+	questionIDs := make([]string, len(order))
+	for i, id := range order {
+		questionIDs[i] = id.String()
+	}
+	return c.JSON(questionIDs)
+}
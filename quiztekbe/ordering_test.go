@@ -0,0 +1,118 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewQuestionOrderMove(t *testing.T) {
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	current := []uuid.UUID{a, b, c, d}
+
+	tests := []struct {
+		name     string
+		from, to int
+		want     []uuid.UUID
+	}{
+		{"down", 1, 3, []uuid.UUID{b, c, a, d}},
+		{"up", 4, 2, []uuid.UUID{a, d, b, c}},
+		{"to the end", 1, 4, []uuid.UUID{b, c, d, a}},
+		{"to the start", 3, 1, []uuid.UUID{c, a, b, d}},
+		{"in place", 2, 2, []uuid.UUID{a, b, c, d}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := newQuestionOrder(current, Question_Reorder{From: ptr(tt.from), To: ptr(tt.to)})
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %+v", errs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if !reflect.DeepEqual(current, []uuid.UUID{a, b, c, d}) {
+		t.Errorf("current order was modified: %v", current)
+	}
+}
+
+func TestNewQuestionOrderErrors(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	current := []uuid.UUID{a, b}
+
+	tests := []struct {
+		name       string
+		current    []uuid.UUID
+		reorder    Question_Reorder
+		wantFields []string
+	}{
+		{"nothing sent", current, Question_Reorder{}, []string{"question_ids"}},
+		{"only from", current, Question_Reorder{From: ptr(1)}, []string{"question_ids"}},
+		{"both ids and a move", current, Question_Reorder{Question_ids: []uuid.UUID{b, a}, From: ptr(1), To: ptr(2)}, []string{"question_ids"}},
+		{"from out of range", current, Question_Reorder{From: ptr(0), To: ptr(1)}, []string{"from"}},
+		{"both out of range", current, Question_Reorder{From: ptr(3), To: ptr(-1)}, []string{"from", "to"}},
+		{"empty quiz", nil, Question_Reorder{From: ptr(1), To: ptr(1)}, []string{"from", "to"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := newQuestionOrder(tt.current, tt.reorder)
+			if got != nil {
+				t.Errorf("order = %v, want none", got)
+			}
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("errors on %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestNewQuestionOrderFullList(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	got, errs := newQuestionOrder([]uuid.UUID{a, b, c}, Question_Reorder{Question_ids: []uuid.UUID{c, a, b}})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+	if !reflect.DeepEqual(got, []uuid.UUID{c, a, b}) {
+		t.Errorf("order = %v, want the ids as sent", got)
+	}
+}
+
+func TestSameQuestionSet(t *testing.T) {
+	a, b, c, stranger := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	current := []uuid.UUID{a, b, c}
+
+	tests := []struct {
+		name      string
+		order     []uuid.UUID
+		wantField string // empty when the order is valid
+		wantText  string
+	}{
+		{"same questions", []uuid.UUID{b, c, a}, "", ""},
+		{"missing one", []uuid.UUID{a, b}, "question_ids", "expected all 3 questions of the quiz, got 2"},
+		{"listed twice", []uuid.UUID{a, b, b}, "question_ids[2]", "listed more than once"},
+		{"from another quiz", []uuid.UUID{a, b, stranger}, "question_ids[2]", "is not in this quiz"},
+		{"extra question", []uuid.UUID{a, b, c, stranger}, "question_ids[3]", "is not in this quiz"},
+		{"empty", []uuid.UUID{}, "question_ids", "got 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := sameQuestionSet(current, tt.order)
+			if tt.wantField == "" {
+				if len(errs) > 0 {
+					t.Errorf("unexpected errors: %+v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.wantField || !strings.Contains(errs[0].Message, tt.wantText) {
+				t.Errorf("errors = %+v, want one on %s containing %q", errs, tt.wantField, tt.wantText)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// quizRole is what the caller is allowed to do with a quiz, higher roles include the lower ones.
+type quizRole int
+
+const (
+	roleNone quizRole = iota
+	roleCollaborator
+	roleOwner
+)
+
+// quizRoleFor returns email's role on the quiz, or pgx.ErrNoRows if the quiz doesn't exist.
+// Admins own every quiz, so the ones migration 0002 left without a creator (NULL, which is
+// why it's coalesced here) can still be edited, or handed to a new owner with PutQuizOwner.
+func quizRoleFor(ctx context.Context, quizID uuid.UUID, email string) (quizRole, error) {
+	queryStr := `
+		SELECT COALESCE(q.creator_email, ''),
+		       EXISTS (SELECT 1 FROM quiz_collaborators qc WHERE qc.quiz_id = q.quiz_id AND qc.email = $2)
+		FROM quizzes q
+		WHERE q.quiz_id = $1
+	`
+	var creatorEmail string
+	var isCollaborator bool
+	if err := db.QueryRow(ctx, queryStr, quizID, email).Scan(&creatorEmail, &isCollaborator); err != nil {
+		return roleNone, err
+	}
+
+	switch {
+	case email != "" && creatorEmail == email, isAdmin(email):
+		return roleOwner, nil
+	case isCollaborator:
+		return roleCollaborator, nil
+	}
+	return roleNone, nil
+}
+
+// authorizeQuiz checks that the logged in user has at least the needed role on the quiz.
+// A non-nil error is the response to send, the handler should return it as is.
+func authorizeQuiz(c *fiber.Ctx, quizID uuid.UUID, need quizRole) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return unauthorized("Login required")
+	}
+
+	role, err := quizRoleFor(context.Background(), quizID, user.Email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Quiz not found")
+		}
+		return internalError("Failed to check permissions", err)
+	}
+
+	if role < need {
+		return forbidden("You don't have permission to change this quiz")
+	}
+	return nil
+}
+
+// authorizeQuestion is authorizeQuiz for routes that only know the question id.
+func authorizeQuestion(c *fiber.Ctx, questionID uuid.UUID, need quizRole) error {
+	var quizID uuid.UUID
+	queryStr := "SELECT quiz_id FROM questions WHERE question_id = $1"
+	if err := db.QueryRow(context.Background(), queryStr, questionID).Scan(&quizID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Question not found")
+		}
+		return internalError("Failed to check permissions", err)
+	}
+	return authorizeQuiz(c, quizID, need)
+}
+
+// GetCollaborators godoc
+// @Summary      List quiz collaborators
+// @Description  List the users who can edit a quiz besides its creator. Only the creator can see this.
+// @Tags         quiz
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Quiz ID"
+// @Success      200  {array}   Quiz_Collaborator
+// @Failure      400  {object}  API_Error  "Invalid quiz ID"
+// @Failure      403  {object}  API_Error  "Not the quiz creator"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /quiz/collaborator/{id} [get]
+func GetCollaborators(c *fiber.Ctx) error {
+	quizIDStr := c.Params("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz ID")
+	}
+	if err := authorizeQuiz(c, quizID, roleOwner); err != nil {
+		return err
+	}
+
+	queryStr := "SELECT email, added_at FROM quiz_collaborators WHERE quiz_id = $1 ORDER BY added_at"
+	rows, err := db.Query(context.Background(), queryStr, quizID)
+	if err != nil {
+		return internalError("Failed to fetch collaborators", err)
+	}
+	defer rows.Close()
+
+	collaborators := []Quiz_Collaborator{}
+	for rows.Next() {
+		var collaborator Quiz_Collaborator
+		if err := rows.Scan(&collaborator.Email, &collaborator.Added_at); err != nil {
+			return internalError("Failed to scan collaborator", err)
+		}
+		collaborators = append(collaborators, collaborator)
+	}
+	return c.JSON(collaborators)
+}
+
+// PostCollaborator godoc
+// @Summary      Add a quiz collaborator
+// @Description  Let another registered user edit the quiz. Only the creator can do this.
+// @Tags         quiz
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string             true  "Quiz ID"
+// @Param        body  body      Collaborator_Post  true  "Collaborator email"
+// @Success      201   {object}  map[string]string  "Collaborator added"
+// @Failure      400   {object}  API_Error  "Invalid quiz ID or email"
+// @Failure      403   {object}  API_Error  "Not the quiz creator"
+// @Failure      404   {object}  API_Error  "Quiz or user not found"
+// @Failure      500   {object}  API_Error  "Internal server error"
+// @Router       /quiz/collaborator/{id} [post]
+func PostCollaborator(c *fiber.Ctx) error {
+	quizIDStr := c.Params("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz ID")
+	}
+	if err := authorizeQuiz(c, quizID, roleOwner); err != nil {
+		return err
+	}
+
+	var collaboratorPost Collaborator_Post
+	if err := c.BodyParser(&collaboratorPost); err != nil {
+		return badRequest("Cannot parse JSON")
+	}
+	email := normalizeEmail(collaboratorPost.Email)
+	if user, _ := currentUser(c); email == "" || email == user.Email {
+		return badRequest("Invalid collaborator email")
+	}
+
+	queryStr := `
+		INSERT INTO quiz_collaborators (quiz_id, email) VALUES ($1, $2)
+		ON CONFLICT (quiz_id, email) DO NOTHING
+	`
+	_, err = db.Exec(context.Background(), queryStr, quizID, email)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation on users
+			return notFound("User not found")
+		}
+		return internalError("Failed to add collaborator", err)
+	}
+	return c.Status(201).JSON(fiber.Map{"message": "Collaborator added", "email": email})
+}
+
+// DeleteCollaborator godoc
+// @Summary      Remove a quiz collaborator
+// @Description  Revoke a collaborator's edit access. Only the creator can do this.
+// @Tags         quiz
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path      string  true  "Quiz ID"
+// @Param        email  path      string  true  "Collaborator email"
+// @Success      200    {object}  map[string]string  "Collaborator removed"
+// @Failure      400    {object}  API_Error  "Invalid quiz ID"
+// @Failure      403    {object}  API_Error  "Not the quiz creator"
+// @Failure      404    {object}  API_Error  "Quiz or collaborator not found"
+// @Failure      500    {object}  API_Error  "Internal server error"
+// @Router       /quiz/collaborator/{id}/{email} [delete]
+func DeleteCollaborator(c *fiber.Ctx) error {
+	quizIDStr := c.Params("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz ID")
+	}
+	if err := authorizeQuiz(c, quizID, roleOwner); err != nil {
+		return err
+	}
+
+	email, err := url.PathUnescape(c.Params("email")) // @ usually arrives as %40
+	if err != nil {
+		return badRequest("Invalid collaborator email")
+	}
+
+	queryStr := "DELETE FROM quiz_collaborators WHERE quiz_id = $1 AND email = $2"
+	tag, err := db.Exec(context.Background(), queryStr, quizID, normalizeEmail(email))
+	if err != nil {
+		return internalError("Failed to remove collaborator", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return notFound("Collaborator not found")
+	}
+	return c.JSON(fiber.Map{"status": "removed"})
+}
+
+// PutQuizOwner godoc
+// @Summary      Change a quiz's owner
+// @Description  Hand the quiz over to another registered user, who becomes its creator. Only the owner or an admin can do this, and admins use it to give quizzes without a creator an owner again. A new owner who was a collaborator stops being one, the old owner keeps no access.
+// @Tags         quiz
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string     true  "Quiz ID"
+// @Param        body  body      Owner_Put  true  "New owner email"
+// @Success      200   {object}  map[string]string  "Owner changed"
+// @Failure      400   {object}  API_Error  "Invalid quiz ID or email"
+// @Failure      403   {object}  API_Error  "Not the quiz creator or an admin"
+// @Failure      404   {object}  API_Error  "Quiz or user not found"
+// @Failure      500   {object}  API_Error  "Internal server error"
+// @Router       /quiz/owner/{id} [put]
+func PutQuizOwner(c *fiber.Ctx) error {
+	quizIDStr := c.Params("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz ID")
+	}
+	if err := authorizeQuiz(c, quizID, roleOwner); err != nil {
+		return err
+	}
+
+	var ownerPut Owner_Put
+	if err := c.BodyParser(&ownerPut); err != nil {
+		return badRequest("Cannot parse JSON")
+	}
+	email := normalizeEmail(ownerPut.Email)
+	if email == "" {
+		return badRequest("Invalid owner email")
+	}
+
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		return internalError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	tag, err := tx.Exec(context.Background(), "UPDATE quizzes SET creator_email = $2 WHERE quiz_id = $1", quizID, email)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation on users
+			return notFound("User not found")
+		}
+		return internalError("Failed to change owner", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return notFound("Quiz not found")
+	}
+	// the owner already has every right a collaborator has
+	queryStr := "DELETE FROM quiz_collaborators WHERE quiz_id = $1 AND email = $2"
+	if _, err := tx.Exec(context.Background(), queryStr, quizID, email); err != nil {
+		return internalError("Failed to update collaborators", err)
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return internalError("Failed to commit transaction", err)
+	}
+	return c.JSON(fiber.Map{"message": "Owner changed", "email": email})
+}
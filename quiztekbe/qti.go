@@ -0,0 +1,648 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// QTI 2.1 packages: a zip with imsmanifest.xml listing one assessmentItem file per
+// question. 'tf', 'mc' and 'ms' become a choiceInteraction, 'fib' one textEntryInteraction
+// per blank. Points travel as a MAXSCORE outcome, penalties and the fib tolerance option
+// have no QTI equivalent and are dropped. Regex fib items are left out of exports, their
+// patterns would read as literal answers and mark every real answer wrong.
+const (
+	qtiNamespace      = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	qtiCPNamespace    = "http://www.imsglobal.org/xsd/imscp_v1p1"
+	qtiItemType       = "imsqti_item_xmlv2p1"
+	qtiMatchCorrect   = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/match_correct"
+	qtiManifestName   = "imsmanifest.xml"
+	qtiMaxFileSize    = 1 << 20 // per file in the zip, items are small
+	qtiMaxPackageSize = 20 << 20
+)
+
+type qtiManifest struct {
+	XMLName    xml.Name      `xml:"manifest"`
+	Xmlns      string        `xml:"xmlns,attr,omitempty"`
+	Identifier string        `xml:"identifier,attr"`
+	Schema     string        `xml:"metadata>schema"`
+	Version    string        `xml:"metadata>schemaversion"`
+	Resources  []qtiResource `xml:"resources>resource"`
+}
+
+type qtiResource struct {
+	Identifier string `xml:"identifier,attr"`
+	Type       string `xml:"type,attr"`
+	Href       string `xml:"href,attr"`
+	File       struct {
+		Href string `xml:"href,attr"`
+	} `xml:"file"`
+}
+
+type qtiItem struct {
+	XMLName       xml.Name                 `xml:"assessmentItem"`
+	Xmlns         string                   `xml:"xmlns,attr,omitempty"`
+	Identifier    string                   `xml:"identifier,attr"`
+	Title         string                   `xml:"title,attr"`
+	Adaptive      bool                     `xml:"adaptive,attr"`
+	TimeDependent bool                     `xml:"timeDependent,attr"`
+	Responses     []qtiResponseDeclaration `xml:"responseDeclaration"`
+	Outcomes      []qtiOutcomeDeclaration  `xml:"outcomeDeclaration"`
+	Body          qtiMarkup                `xml:"itemBody"`
+	Processing    *qtiResponseProcessing   `xml:"responseProcessing"`
+}
+
+type qtiResponseDeclaration struct {
+	Identifier  string      `xml:"identifier,attr"`
+	Cardinality string      `xml:"cardinality,attr"`
+	BaseType    string      `xml:"baseType,attr"`
+	Correct     []string    `xml:"correctResponse>value"`
+	Mapping     *qtiMapping `xml:"mapping"`
+}
+
+type qtiMapping struct {
+	DefaultValue float64       `xml:"defaultValue,attr"`
+	Entries      []qtiMapEntry `xml:"mapEntry"`
+}
+
+type qtiMapEntry struct {
+	Key           string  `xml:"mapKey,attr"`
+	Value         float64 `xml:"mappedValue,attr"`
+	CaseSensitive *bool   `xml:"caseSensitive,attr"`
+}
+
+type qtiOutcomeDeclaration struct {
+	Identifier  string `xml:"identifier,attr"`
+	Cardinality string `xml:"cardinality,attr"`
+	BaseType    string `xml:"baseType,attr"`
+	Default     string `xml:"defaultValue>value"`
+}
+
+type qtiResponseProcessing struct {
+	Template string `xml:"template,attr"`
+}
+
+// qtiMarkup keeps an element's content as raw XML, since item bodies mix text and markup.
+type qtiMarkup struct {
+	Inner string `xml:",innerxml"`
+}
+
+type qtiChoiceInteraction struct {
+	XMLName            xml.Name          `xml:"choiceInteraction"`
+	ResponseIdentifier string            `xml:"responseIdentifier,attr"`
+	Shuffle            bool              `xml:"shuffle,attr"`
+	MaxChoices         int               `xml:"maxChoices,attr"`
+	Prompt             *qtiMarkup        `xml:"prompt"`
+	Choices            []qtiSimpleChoice `xml:"simpleChoice"`
+}
+
+type qtiSimpleChoice struct {
+	Identifier string `xml:"identifier,attr"`
+	Inner      string `xml:",innerxml"`
+}
+
+// qtiSkipped is an item that was left out of an import, and why.
+type qtiSkipped struct {
+	File       string `json:"file"`
+	Identifier string `json:"identifier"`
+	Reason     string `json:"reason"`
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// plainText strips the markup from an XML fragment and collapses its whitespace.
+func plainText(fragment string) string {
+	d := xml.NewDecoder(strings.NewReader(fragment))
+	d.Strict = false
+	var b strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		if text, ok := tok.(xml.CharData); ok {
+			b.Write(text)
+			b.WriteByte(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func qtiItemID(questionID uuid.UUID) string {
+	return "item-" + questionID.String()
+}
+
+// qtiChoiceID names choice i, identifiers have to start with a letter.
+func qtiChoiceID(i int) string {
+	return "choice_" + strconv.Itoa(i)
+}
+
+// questionToQTI builds the assessmentItem for one question.
+func questionToQTI(question Question) (qtiItem, error) {
+	item := qtiItem{
+		Xmlns:      qtiNamespace,
+		Identifier: qtiItemID(question.Question_id),
+		Title:      fmt.Sprintf("Question %d", question.Position),
+		Outcomes: []qtiOutcomeDeclaration{
+			{Identifier: "SCORE", Cardinality: "single", BaseType: "float", Default: "0"},
+			{Identifier: "MAXSCORE", Cardinality: "single", BaseType: "float", Default: strconv.FormatFloat(question.Points, 'f', -1, 64)},
+		},
+		Processing: &qtiResponseProcessing{Template: qtiMatchCorrect},
+	}
+
+	switch question.Type {
+	case "tf", "mc", "ms":
+		interaction := qtiChoiceInteraction{
+			ResponseIdentifier: "RESPONSE",
+			MaxChoices:         1,
+			Prompt:             &qtiMarkup{Inner: escapeXML(question.Message)},
+		}
+		decl := qtiResponseDeclaration{Identifier: "RESPONSE", Cardinality: "single", BaseType: "identifier"}
+
+		switch question.Type {
+		case "tf":
+			interaction.Choices = []qtiSimpleChoice{{Identifier: "true", Inner: "True"}, {Identifier: "false", Inner: "False"}}
+			if question.Answer_tf != nil {
+				decl.Correct = []string{strconv.FormatBool(*question.Answer_tf)}
+			}
+		case "mc":
+			interaction.Choices = qtiChoices(question.Choices)
+			if question.Correct_choice != nil {
+				decl.Correct = []string{qtiChoiceID(*question.Correct_choice)}
+			}
+		case "ms":
+			interaction.Choices = qtiChoices(question.Choices)
+			interaction.MaxChoices = 0 // unlimited
+			decl.Cardinality = "multiple"
+			for _, choice := range question.Correct_choices {
+				decl.Correct = append(decl.Correct, qtiChoiceID(choice))
+			}
+		}
+
+		body, err := xml.Marshal(interaction)
+		if err != nil {
+			return item, err
+		}
+		item.Responses = []qtiResponseDeclaration{decl}
+		item.Body.Inner = string(body)
+
+	case "fib":
+		var body strings.Builder
+		fmt.Fprintf(&body, "<p>%s</p>", escapeXML(question.Message))
+		opts := question.Fib_options
+		if opts == nil {
+			opts = &Fib_Options{}
+		}
+		for i, answer := range question.Correct_answers {
+			responseID := fmt.Sprintf("RESPONSE_%d", i+1)
+			decl := qtiResponseDeclaration{Identifier: responseID, Cardinality: "single", BaseType: "string", Correct: []string{answer}}
+			if accepted := opts.accepted(question.Correct_answers, i); len(accepted) > 1 || opts.Case_insensitive {
+				caseSensitive := !opts.Case_insensitive
+				decl.Mapping = &qtiMapping{}
+				for _, key := range accepted {
+					decl.Mapping.Entries = append(decl.Mapping.Entries, qtiMapEntry{Key: key, Value: 1, CaseSensitive: &caseSensitive})
+				}
+			}
+			item.Responses = append(item.Responses, decl)
+			fmt.Fprintf(&body, `<p><textEntryInteraction responseIdentifier="%s"/></p>`, responseID)
+		}
+		item.Body.Inner = body.String()
+
+	default:
+		return item, fmt.Errorf("question %s has unknown type %q", question.Question_id, question.Type)
+	}
+	return item, nil
+}
+
+func qtiChoices(choices []string) []qtiSimpleChoice {
+	out := make([]qtiSimpleChoice, len(choices))
+	for i, choice := range choices {
+		out[i] = qtiSimpleChoice{Identifier: qtiChoiceID(i), Inner: escapeXML(choice)}
+	}
+	return out
+}
+
+// qtiUnexportable says why a question can't go into a QTI package, "" when it can.
+func qtiUnexportable(question Question) string {
+	if question.Type == "fib" && question.Fib_options != nil && question.Fib_options.Regex {
+		return "fib answers are regular expressions, QTI only has literal answers"
+	}
+	return ""
+}
+
+// writeQTIPackage zips the manifest and one item file per question, and returns the ids
+// of the questions it had to leave out.
+func writeQTIPackage(w io.Writer, quizID uuid.UUID, questions []Question) ([]uuid.UUID, error) {
+	zw := zip.NewWriter(w)
+	manifest := qtiManifest{
+		Xmlns:      qtiCPNamespace,
+		Identifier: "manifest-" + quizID.String(),
+		Schema:     "QTIv2.1 Package",
+		Version:    "1.0.0",
+	}
+
+	var skipped []uuid.UUID
+	for _, question := range questions {
+		if qtiUnexportable(question) != "" {
+			skipped = append(skipped, question.Question_id)
+			continue
+		}
+		item, err := questionToQTI(question)
+		if err != nil {
+			return nil, err
+		}
+		href := "items/" + item.Identifier + ".xml"
+		if err := writeXMLFile(zw, href, item); err != nil {
+			return nil, err
+		}
+		resource := qtiResource{Identifier: item.Identifier, Type: qtiItemType, Href: href}
+		resource.File.Href = href
+		manifest.Resources = append(manifest.Resources, resource)
+	}
+
+	if err := writeXMLFile(zw, qtiManifestName, manifest); err != nil {
+		return nil, err
+	}
+	return skipped, zw.Close()
+}
+
+func writeXMLFile(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	return enc.Encode(v)
+}
+
+// readQTIFile reads one file out of the package, refusing anything suspiciously large.
+func readQTIFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, qtiMaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > qtiMaxFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", f.Name, qtiMaxFileSize)
+	}
+	return data, nil
+}
+
+// qtiItemFiles returns the item files the package's manifest lists, in manifest order.
+func qtiItemFiles(zr *zip.Reader) ([]*zip.File, error) {
+	files := make(map[string]*zip.File, len(zr.File))
+	var manifestFile *zip.File
+	for _, f := range zr.File {
+		files[f.Name] = f
+		if path.Base(f.Name) == qtiManifestName && (manifestFile == nil || len(f.Name) < len(manifestFile.Name)) {
+			manifestFile = f
+		}
+	}
+	if manifestFile == nil {
+		return nil, errors.New("package has no " + qtiManifestName)
+	}
+
+	data, err := readQTIFile(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	var manifest qtiManifest
+	if err := xml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", qtiManifestName, err)
+	}
+
+	var items []*zip.File
+	for _, resource := range manifest.Resources {
+		if !strings.HasPrefix(resource.Type, "imsqti_item") {
+			continue
+		}
+		href := resource.Href
+		if href == "" {
+			href = resource.File.Href
+		}
+		name := path.Join(path.Dir(manifestFile.Name), href)
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("manifest lists %s but the package doesn't contain it", href)
+		}
+		items = append(items, f)
+	}
+	return items, nil
+}
+
+// qtiToQuestion maps an assessmentItem onto a question. A non-empty reason means the
+// item uses something this importer doesn't support and should be skipped.
+func qtiToQuestion(item qtiItem) (question Question, reason string) {
+	question.Points = 1
+	for _, outcome := range item.Outcomes {
+		if outcome.Identifier == "MAXSCORE" {
+			if points, err := strconv.ParseFloat(strings.TrimSpace(outcome.Default), 64); err == nil {
+				question.Points = points
+			}
+		}
+	}
+	responses := make(map[string]qtiResponseDeclaration, len(item.Responses))
+	for _, decl := range item.Responses {
+		responses[decl.Identifier] = decl
+	}
+
+	var choiceInteractions []qtiChoiceInteraction
+	var textEntries []string
+	var text strings.Builder
+	d := xml.NewDecoder(strings.NewReader(item.Body.Inner))
+	d.Strict = false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return question, "invalid itemBody: " + err.Error()
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch name := t.Name.Local; {
+			case name == "choiceInteraction":
+				var interaction qtiChoiceInteraction
+				if err := d.DecodeElement(&interaction, &t); err != nil {
+					return question, "invalid choiceInteraction: " + err.Error()
+				}
+				choiceInteractions = append(choiceInteractions, interaction)
+			case name == "textEntryInteraction":
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "responseIdentifier" {
+						textEntries = append(textEntries, attr.Value)
+					}
+				}
+				d.Skip()
+			case strings.HasSuffix(name, "Interaction"):
+				return question, name + " is not supported"
+			}
+		case xml.CharData:
+			text.Write(t)
+			text.WriteByte(' ')
+		}
+	}
+	message := strings.Join(strings.Fields(text.String()), " ")
+
+	switch {
+	case len(choiceInteractions) == 1 && len(textEntries) == 0:
+		interaction := choiceInteractions[0]
+		if interaction.Prompt != nil {
+			message = strings.TrimSpace(plainText(interaction.Prompt.Inner) + " " + message)
+		}
+		question.Message = message
+		decl := responses[interaction.ResponseIdentifier]
+		index := make(map[string]int, len(interaction.Choices))
+		for i, choice := range interaction.Choices {
+			index[choice.Identifier] = i
+			question.Choices = append(question.Choices, plainText(choice.Inner))
+		}
+
+		if decl.Cardinality == "multiple" {
+			question.Type = "ms"
+			for _, value := range decl.Correct {
+				if i, ok := index[strings.TrimSpace(value)]; ok {
+					question.Correct_choices = append(question.Correct_choices, i)
+				}
+			}
+			return question, ""
+		}
+		if decl.Cardinality == "ordered" {
+			return question, "ordered choice responses are not supported"
+		}
+
+		if isTrueFalse(question.Choices) {
+			question.Type = "tf"
+			if len(decl.Correct) > 0 {
+				if i, ok := index[strings.TrimSpace(decl.Correct[0])]; ok {
+					answer := strings.EqualFold(question.Choices[i], "true")
+					question.Answer_tf = &answer
+				}
+			}
+			question.Choices = nil
+			return question, ""
+		}
+
+		question.Type = "mc"
+		if len(decl.Correct) > 0 {
+			if i, ok := index[strings.TrimSpace(decl.Correct[0])]; ok {
+				question.Correct_choice = &i
+			}
+		}
+		return question, ""
+
+	case len(choiceInteractions) == 0 && len(textEntries) > 0:
+		question.Type = "fib"
+		question.Message = message
+		opts := &Fib_Options{}
+		hasOptions := false
+		for _, responseID := range textEntries {
+			decl := responses[responseID]
+			var accepted []string
+			accepted = append(accepted, decl.Correct...)
+			if decl.Mapping != nil {
+				for _, entry := range decl.Mapping.Entries {
+					if entry.Value <= 0 {
+						continue
+					}
+					if entry.CaseSensitive != nil && !*entry.CaseSensitive {
+						opts.Case_insensitive = true
+						hasOptions = true
+					}
+					if !containsString(accepted, entry.Key) {
+						accepted = append(accepted, entry.Key)
+					}
+				}
+			}
+			if len(accepted) == 0 {
+				question.Correct_answers = append(question.Correct_answers, "")
+				opts.Alternatives = append(opts.Alternatives, nil)
+				continue
+			}
+			question.Correct_answers = append(question.Correct_answers, accepted[0])
+			opts.Alternatives = append(opts.Alternatives, accepted[1:])
+			if len(accepted) > 1 {
+				hasOptions = true
+			}
+		}
+		if hasOptions {
+			question.Fib_options = opts
+		}
+		return question, ""
+
+	case len(choiceInteractions) == 0 && len(textEntries) == 0:
+		return question, "item has no interaction"
+	}
+	return question, "items with more than one kind of interaction are not supported"
+}
+
+func isTrueFalse(choices []string) bool {
+	return len(choices) == 2 &&
+		(strings.EqualFold(choices[0], "true") && strings.EqualFold(choices[1], "false") ||
+			strings.EqualFold(choices[0], "false") && strings.EqualFold(choices[1], "true"))
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportQuizQTI godoc
+// @Summary      Export a quiz as QTI 2.1
+// @Description  Download a quiz as an IMS QTI 2.1 content package: a zip of one assessmentItem per question plus imsmanifest.xml. 'tf', 'mc' and 'ms' become choiceInteractions, 'fib' a textEntryInteraction per blank. Fib questions with regex answers can't be expressed in QTI, they're left out and listed in the X-QTI-Skipped header.
+// @Tags         quiz
+// @Produce      application/zip
+// @Param        id   path      string  true  "Quiz ID"
+// @Success      200  {file}    file
+// @Header       200  {string}  X-QTI-Skipped  "Comma separated ids of the questions left out, only sent when there are any"
+// @Failure      400  {object}  API_Error  "Invalid quiz ID"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /quiz/export/qti/{id} [get]
+func ExportQuizQTI(c *fiber.Ctx) error {
+	quizIDStr := c.Params("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz ID")
+	}
+
+	tx, err := db.BeginTx(context.Background(), pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return internalError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	if exists, err := quizExists(context.Background(), tx, quizID); err != nil {
+		return internalError("Failed to fetch quiz", err)
+	} else if !exists {
+		return notFound("Quiz not found")
+	}
+
+	questions, err := quizQuestions(context.Background(), tx, quizID)
+	if err != nil {
+		return internalError("Failed to fetch questions", err)
+	}
+
+	var buf bytes.Buffer
+	skipped, err := writeQTIPackage(&buf, quizID, questions)
+	if err != nil {
+		return internalError("Failed to build QTI package", err)
+	}
+	if len(skipped) > 0 {
+		ids := make([]string, len(skipped))
+		for i, id := range skipped {
+			ids[i] = id.String()
+		}
+		c.Set("X-QTI-Skipped", strings.Join(ids, ","))
+	}
+	c.Attachment(fmt.Sprintf("quiz-%s-qti.zip", quizID))
+	return c.Send(buf.Bytes())
+}
+
+// ImportQuizQTI godoc
+// @Summary      Import a QTI 2.1 package
+// @Description  Create a new quiz owned by the caller from an IMS QTI 2.1 content package. Items using interactions other than choice and text entry are skipped and listed in the response. If any supported item turns out invalid nothing is created.
+// @Tags         quiz
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file      formData  file    true   "QTI zip package"
+// @Param        title     formData  string  false  "Quiz title, defaults to the file name"
+// @Param        category  formData  string  false  "Quiz category"
+// @Success      201  {object}  map[string]interface{}  "New quiz id, question count and skipped items"
+// @Failure      400  {object}  API_Error  "Missing or unreadable package"
+// @Failure      401  {object}  API_Error  "Login required"
+// @Failure      422  {object}  API_Error  "No supported items, or items fail validation"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /quiz/import/qti [post]
+func ImportQuizQTI(c *fiber.Ctx) error {
+	user, _ := currentUser(c)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return badRequest("A QTI zip is required in the file field")
+	}
+	if fileHeader.Size > qtiMaxPackageSize {
+		return badRequest("QTI package is too large")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return internalError("Failed to read upload", err)
+	}
+	defer file.Close()
+
+	zr, err := zip.NewReader(file, fileHeader.Size)
+	if err != nil {
+		return badRequest("File is not a zip archive")
+	}
+	itemFiles, err := qtiItemFiles(zr)
+	if err != nil {
+		return badRequest(err.Error())
+	}
+
+	var questions []Question
+	skipped := []qtiSkipped{}
+	var fieldErrs []Field_Error
+	for _, f := range itemFiles {
+		data, err := readQTIFile(f)
+		if err != nil {
+			return badRequest(err.Error())
+		}
+		var item qtiItem
+		if err := xml.Unmarshal(data, &item); err != nil {
+			skipped = append(skipped, qtiSkipped{File: f.Name, Reason: "not an assessmentItem: " + err.Error()})
+			continue
+		}
+		question, reason := qtiToQuestion(item)
+		if reason != "" {
+			skipped = append(skipped, qtiSkipped{File: f.Name, Identifier: item.Identifier, Reason: reason})
+			continue
+		}
+		fieldErrs = append(fieldErrs, prefixFieldErrors(f.Name, validateQuestion(questionContent(question)))...)
+		questions = append(questions, question)
+	}
+	if len(fieldErrs) > 0 {
+		return validationFailed("Invalid QTI items", fieldErrs)
+	}
+	if len(questions) == 0 {
+		return validationFailed("Package has no supported items", nil).withDetails(fiber.Map{"skipped": skipped})
+	}
+
+	quiz := Quiz{Title: c.FormValue("title"), Category: c.FormValue("category")}
+	if quiz.Title == "" {
+		quiz.Title = strings.TrimSuffix(path.Base(fileHeader.Filename), path.Ext(fileHeader.Filename))
+	}
+	quizID, err := importQuiz(context.Background(), user.Email, quiz, questions)
+	if err != nil {
+		return internalError("Failed to import quiz", err)
+	}
+	return c.Status(201).JSON(fiber.Map{"message": "Quiz imported", "id": quizID, "questions": len(questions), "skipped": skipped})
+}
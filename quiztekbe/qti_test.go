@@ -0,0 +1,119 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// roundTripQTI exports questions as a QTI package and imports it again the way
+// ImportQuizQTI reads it, returning the imported questions and the skipped ids.
+func roundTripQTI(t *testing.T, questions []Question) ([]Question, []uuid.UUID) {
+	t.Helper()
+	var buf bytes.Buffer
+	skipped, err := writeQTIPackage(&buf, uuid.New(), questions)
+	if err != nil {
+		t.Fatalf("writeQTIPackage: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	files, err := qtiItemFiles(zr)
+	if err != nil {
+		t.Fatalf("qtiItemFiles: %v", err)
+	}
+
+	var imported []Question
+	for _, f := range files {
+		data, err := readQTIFile(f)
+		if err != nil {
+			t.Fatalf("readQTIFile(%s): %v", f.Name, err)
+		}
+		var item qtiItem
+		if err := xml.Unmarshal(data, &item); err != nil {
+			t.Fatalf("unmarshal %s: %v", f.Name, err)
+		}
+		question, reason := qtiToQuestion(item)
+		if reason != "" {
+			t.Fatalf("%s skipped on import: %s", f.Name, reason)
+		}
+		imported = append(imported, question)
+	}
+	return imported, skipped
+}
+
+func TestQTIRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		question Question
+		want     Question // what comes back, penalties and tolerances don't survive
+	}{
+		{
+			"tf",
+			Question{Type: "tf", Message: "Water boils at 100C", Answer_tf: ptr(false), Points: 2},
+			Question{Type: "tf", Message: "Water boils at 100C", Answer_tf: ptr(false), Points: 2},
+		},
+		{
+			"mc",
+			Question{Type: "mc", Message: "Capital of France?", Choices: []string{"Lyon", "Paris", "Nice & <Cannes>"}, Correct_choice: ptr(1), Points: 1, Penalty: 0.5},
+			Question{Type: "mc", Message: "Capital of France?", Choices: []string{"Lyon", "Paris", "Nice & <Cannes>"}, Correct_choice: ptr(1), Points: 1},
+		},
+		{
+			"ms",
+			Question{Type: "ms", Message: "Primes?", Choices: []string{"2", "4", "5"}, Correct_choices: []int{0, 2}, Points: 3},
+			Question{Type: "ms", Message: "Primes?", Choices: []string{"2", "4", "5"}, Correct_choices: []int{0, 2}, Points: 3},
+		},
+		{
+			"fib",
+			Question{Type: "fib", Message: "_ is the capital of _", Correct_answers: []string{"Paris", "France"}, Points: 1},
+			Question{Type: "fib", Message: "_ is the capital of _", Correct_answers: []string{"Paris", "France"}, Points: 1},
+		},
+		{
+			"fib with options",
+			Question{
+				Type: "fib", Message: "Red planet: _", Correct_answers: []string{"Mars"}, Points: 1,
+				Fib_options: &Fib_Options{Case_insensitive: true, Alternatives: [][]string{{"Planet Mars"}}, Numeric_tolerance: ptr(0.5)},
+			},
+			Question{
+				Type: "fib", Message: "Red planet: _", Correct_answers: []string{"Mars"}, Points: 1,
+				Fib_options: &Fib_Options{Case_insensitive: true, Alternatives: [][]string{{"Planet Mars"}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.question.Question_id = uuid.New()
+			imported, skipped := roundTripQTI(t, []Question{tt.question})
+			if len(skipped) > 0 {
+				t.Fatalf("skipped = %v, want none", skipped)
+			}
+			if len(imported) != 1 {
+				t.Fatalf("imported %d questions, want 1", len(imported))
+			}
+			if got := imported[0]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("round trip =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQTIExportSkipsRegexFib(t *testing.T) {
+	regex := Question{
+		Question_id: uuid.New(), Type: "fib", Message: "Any year in the 1900s: _",
+		Correct_answers: []string{`19\d\d`}, Fib_options: &Fib_Options{Regex: true}, Points: 1,
+	}
+	plain := Question{Question_id: uuid.New(), Type: "tf", Message: "Sky is blue", Answer_tf: ptr(true), Points: 1}
+
+	imported, skipped := roundTripQTI(t, []Question{regex, plain})
+	if !reflect.DeepEqual(skipped, []uuid.UUID{regex.Question_id}) {
+		t.Errorf("skipped = %v, want [%s]", skipped, regex.Question_id)
+	}
+	if len(imported) != 1 || imported[0].Type != "tf" {
+		t.Errorf("imported = %+v, want only the tf question", imported)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// searchConfig is the text search configuration the search_vector columns are built with,
+// queries have to use the same one to match.
+const searchConfig = "english"
+
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=25, MinWords=10, MaxFragments=2"
+
+// escapeHTMLSQL wraps a SQL text expression so ts_headline's output is safe to render,
+// the only markup left in it is the <mark> tags.
+func escapeHTMLSQL(expr string) string {
+	return fmt.Sprintf("replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;')", expr)
+}
+
+// SearchQuizzes godoc
+// @Summary      Search quizzes
+// @Description  Full-text search over quiz titles and categories and their questions' messages and choices, best matches first. q takes web search syntax: quoted phrases, OR, and -word to exclude.
+// @Tags         quiz
+// @Produce      json
+// @Param        q       query     string  true   "Search terms"
+// @Param        limit   query     int     false  "Page size, 1 to 100, default 20"
+// @Param        offset  query     int     false  "Results to skip"
+// @Success      200  {array}   Quiz_Search_Result
+// @Failure      422  {object}  API_Error  "Missing search terms or invalid paging"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /quiz/search [get]
+func SearchQuizzes(c *fiber.Ctx) error {
+	var fieldErrs []Field_Error
+	terms := strings.TrimSpace(c.Query("q"))
+	if terms == "" {
+		fieldErrs = append(fieldErrs, Field_Error{Field: "q", Message: "search terms are required"})
+	}
+	limit, offset := defaultPageSize, 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 || n > maxPageSize {
+			fieldErrs = append(fieldErrs, Field_Error{Field: "limit", Message: fmt.Sprintf("limit must be between 1 and %d", maxPageSize)})
+		}
+		limit = n
+	}
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		n, err := strconv.Atoi(offsetStr)
+		if err != nil || n < 0 {
+			fieldErrs = append(fieldErrs, Field_Error{Field: "offset", Message: "offset can't be negative"})
+		}
+		offset = n
+	}
+	if len(fieldErrs) > 0 {
+		return validationFailed("Invalid search", fieldErrs)
+	}
+
+	// a quiz ranks by its own match plus every matching question, so quizzes that are
+	// about the terms throughout beat ones that mention them once
+	queryStr := fmt.Sprintf(`
+		WITH query AS (
+			SELECT websearch_to_tsquery('%[1]s', $1) AS tsq
+		), matches AS (
+			SELECT q.quiz_id, ts_rank(q.search_vector, query.tsq) AS rank
+			FROM quizzes q, query
+			WHERE q.search_vector @@ query.tsq
+			UNION ALL
+			SELECT qs.quiz_id, ts_rank(qs.search_vector, query.tsq)
+			FROM questions qs, query
+			WHERE qs.search_vector @@ query.tsq
+		), ranked AS (
+			SELECT quiz_id, SUM(rank) AS rank
+			FROM matches
+			GROUP BY quiz_id
+		)
+		SELECT q.quiz_id, q.title, q.category, q.category_id, COALESCE(q.creator_email, ''), q.created_at, q.time_limit_seconds,
+		       r.rank,
+		       ts_headline('%[1]s', %[2]s, query.tsq, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>'),
+		       (SELECT ts_headline('%[1]s', %[3]s, query.tsq, '%[4]s')
+		        FROM questions qs
+		        WHERE qs.quiz_id = q.quiz_id AND qs.search_vector @@ query.tsq
+		        ORDER BY ts_rank(qs.search_vector, query.tsq) DESC
+		        LIMIT 1)
+		FROM ranked r
+		JOIN quizzes q ON q.quiz_id = r.quiz_id
+		CROSS JOIN query
+		ORDER BY r.rank DESC, q.quiz_id
+		LIMIT $2 OFFSET $3
+	`, searchConfig,
+		escapeHTMLSQL("COALESCE(q.title, '')"),
+		escapeHTMLSQL("qs.message || ' ' || COALESCE(array_to_string(qs.choices, ' '), '')"),
+		headlineOptions,
+	)
+
+	rows, err := db.Query(context.Background(), queryStr, terms, limit, offset)
+	if err != nil {
+		return internalError("Failed to search quizzes", err)
+	}
+	defer rows.Close()
+
+	results := []Quiz_Search_Result{}
+	for rows.Next() {
+		var result Quiz_Search_Result
+		quiz := &result.Quiz
+		if err := rows.Scan(&quiz.Quiz_id, &quiz.Title, &quiz.Category, &quiz.Category_id, &quiz.Creator_email, &quiz.Created_at, &quiz.Time_limit,
+			&result.Rank, &result.Title_highlight, &result.Snippet); err != nil {
+			return internalError("Failed to scan search result", err)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return internalError("Failed to search quizzes", err)
+	}
+	return c.JSON(results)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	maxTagLength   = 50
+	maxTagsPerPost = 20
+)
+
+// nonSlugChars is what category_slug in migration 0006 turns into a dash.
+var nonSlugChars = regexp.MustCompile("[^a-z0-9]+")
+
+// tagSlug is the name a tag is stored under, the same as category_slug gives in SQL.
+// The database still does the normalizing, this only lets validation see the result.
+func tagSlug(tag string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(tag)), "-"), "-")
+}
+
+func validateTags(tags []string) []Field_Error {
+	if len(tags) == 0 {
+		return []Field_Error{{Field: "tags", Message: "at least one tag is required"}}
+	}
+	if len(tags) > maxTagsPerPost {
+		return []Field_Error{{Field: "tags", Message: fmt.Sprintf("at most %d tags at a time", maxTagsPerPost)}}
+	}
+	var errs []Field_Error
+	for i, tag := range tags {
+		field := fmt.Sprintf("tags[%d]", i)
+		switch tag = strings.TrimSpace(tag); {
+		case tag == "":
+			errs = append(errs, Field_Error{Field: field, Message: "tag can't be blank"})
+		case len(tag) > maxTagLength:
+			errs = append(errs, Field_Error{Field: field, Message: fmt.Sprintf("tag can't be longer than %d characters", maxTagLength)})
+		case tagSlug(tag) == "":
+			// would otherwise be dropped without a word, e.g. "!!!" or "数学"
+			errs = append(errs, Field_Error{Field: field, Message: "tag needs at least one letter a-z or digit"})
+		}
+	}
+	return errs
+}
+
+// quizTagNames returns the quiz's tags in alphabetical order.
+func quizTagNames(ctx context.Context, q dbtx, quizID uuid.UUID) ([]string, error) {
+	queryStr := "SELECT t.name FROM quiz_tags qt JOIN tags t ON t.tag_id = qt.tag_id WHERE qt.quiz_id = $1 ORDER BY t.name"
+	rows, err := q.Query(ctx, queryStr, quizID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// GetQuizTags godoc
+// @Summary      List a quiz's tags
+// @Description  Get the tags on a quiz in alphabetical order.
+// @Tags         quiz, tag
+// @Produce      json
+// @Param        id   path      string  true  "Quiz ID"
+// @Success      200  {array}   string
+// @Failure      400  {object}  API_Error  "Invalid quiz ID"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /quiz/tag/{id} [get]
+func GetQuizTags(c *fiber.Ctx) error {
+	quizIDStr := c.Params("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz ID")
+	}
+
+	if exists, err := quizExists(context.Background(), db, quizID); err != nil {
+		return internalError("Failed to fetch quiz", err)
+	} else if !exists {
+		return notFound("Quiz not found")
+	}
+
+	tags, err := quizTagNames(context.Background(), db, quizID)
+	if err != nil {
+		return internalError("Failed to fetch tags", err)
+	}
+	return c.JSON(tags)
+}
+
+// PostQuizTags godoc
+// @Summary      Tag a quiz
+// @Description  Add tags to a quiz, creating any that don't exist yet. Tags are stored lowercase with runs of other characters turned into dashes, tags the quiz already has are ignored.
+// @Tags         quiz, tag
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string          true  "Quiz ID"
+// @Param        body  body      Quiz_Tags_Post  true  "Tags to add"
+// @Success      200   {array}   string                  "The quiz's tags afterwards"
+// @Failure      400   {object}  API_Error  "Invalid quiz ID or JSON payload"
+// @Failure      403   {object}  API_Error  "Not the creator or a collaborator"
+// @Failure      404   {object}  API_Error  "Quiz not found"
+// @Failure      422   {object}  API_Error  "Invalid tags"
+// @Failure      500   {object}  API_Error  "Internal server error"
+// @Router       /quiz/tag/{id} [post]
+func PostQuizTags(c *fiber.Ctx) error {
+	quizIDStr := c.Params("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz ID")
+	}
+	if err := authorizeQuiz(c, quizID, roleCollaborator); err != nil {
+		return err
+	}
+
+	var tagsPost Quiz_Tags_Post
+	if err := c.BodyParser(&tagsPost); err != nil {
+		return badRequest("Cannot parse JSON")
+	}
+	if fieldErrs := validateTags(tagsPost.Tags); len(fieldErrs) > 0 {
+		return validationFailed("Invalid tags", fieldErrs)
+	}
+
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		return internalError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	// separate statements so the second one sees tags another request created meanwhile
+	queryStr := "INSERT INTO tags (name) SELECT DISTINCT slug FROM " + fmt.Sprintf(tagNames, "$1") + " AS requested(slug) ON CONFLICT (name) DO NOTHING"
+	if _, err := tx.Exec(context.Background(), queryStr, tagsPost.Tags); err != nil {
+		return internalError("Failed to create tags", err)
+	}
+	queryStr = `
+		INSERT INTO quiz_tags (quiz_id, tag_id)
+		SELECT $1, tag_id FROM tags WHERE name IN ` + fmt.Sprintf(tagNames, "$2") + `
+		ON CONFLICT (quiz_id, tag_id) DO NOTHING
+	`
+	if _, err := tx.Exec(context.Background(), queryStr, quizID, tagsPost.Tags); err != nil {
+		return internalError("Failed to tag quiz", err)
+	}
+
+	tags, err := quizTagNames(context.Background(), tx, quizID)
+	if err != nil {
+		return internalError("Failed to fetch tags", err)
+	}
+	if err = tx.Commit(context.Background()); err != nil {
+		return internalError("Failed to commit transaction", err)
+	}
+	return c.JSON(tags)
+}
+
+// DeleteQuizTag godoc
+// @Summary      Untag a quiz
+// @Description  Remove one tag from a quiz.
+// @Tags         quiz, tag
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Quiz ID"
+// @Param        tag  path      string  true  "Tag"
+// @Success      200  {object}  map[string]string  "Tag removed"
+// @Failure      400  {object}  API_Error  "Invalid quiz ID or tag"
+// @Failure      403  {object}  API_Error  "Not the creator or a collaborator"
+// @Failure      404  {object}  API_Error  "Quiz not found or doesn't have the tag"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /quiz/tag/{id}/{tag} [delete]
+func DeleteQuizTag(c *fiber.Ctx) error {
+	quizIDStr := c.Params("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz ID")
+	}
+	if err := authorizeQuiz(c, quizID, roleCollaborator); err != nil {
+		return err
+	}
+
+	tagName, err := url.PathUnescape(c.Params("tag"))
+	if err != nil {
+		return badRequest("Invalid tag")
+	}
+
+	queryStr := `
+		DELETE FROM quiz_tags qt
+		USING tags t
+		WHERE qt.tag_id = t.tag_id AND qt.quiz_id = $1 AND t.name = category_slug($2)
+	`
+	tag, err := db.Exec(context.Background(), queryStr, quizID, tagName)
+	if err != nil {
+		return internalError("Failed to remove tag", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return notFound("Quiz doesn't have this tag")
+	}
+	return c.JSON(fiber.Map{"status": "removed"})
+}
+
+// GetTagCloud godoc
+// @Summary      Tag cloud
+// @Description  The most used tags with how many quizzes have each, most used first.
+// @Tags         tag
+// @Produce      json
+// @Param        limit  query     int  false  "How many tags, 1 to 100, default 50"
+// @Success      200    {array}   Tag_Count
+// @Failure      422    {object}  API_Error  "Invalid limit"
+// @Failure      500    {object}  API_Error  "Internal server error"
+// @Router       /tag [get]
+func GetTagCloud(c *fiber.Ctx) error {
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 || n > maxPageSize {
+			return validationFailed("Invalid tag cloud", []Field_Error{{Field: "limit", Message: fmt.Sprintf("limit must be between 1 and %d", maxPageSize)}})
+		}
+		limit = n
+	}
+
+	queryStr := `
+		SELECT t.name, COUNT(*)
+		FROM tags t
+		JOIN quiz_tags qt ON qt.tag_id = t.tag_id
+		GROUP BY t.name
+		ORDER BY COUNT(*) DESC, t.name
+		LIMIT $1
+	`
+	rows, err := db.Query(context.Background(), queryStr, limit)
+	if err != nil {
+		return internalError("Failed to fetch tags", err)
+	}
+	defer rows.Close()
+
+	counts := []Tag_Count{}
+	for rows.Next() {
+		var count Tag_Count
+		if err := rows.Scan(&count.Name, &count.Count); err != nil {
+			return internalError("Failed to scan tag", err)
+		}
+		counts = append(counts, count)
+	}
+	return c.JSON(counts)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTagSlug(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"go", "go"},
+		{"  Go Lang ", "go-lang"},
+		{"C++ / Rust", "c-rust"},
+		{"--world--war--2--", "world-war-2"},
+		{"Éclair", "clair"},
+		{"!!!", ""},
+		{"数学", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			if got := tagSlug(tt.tag); got != tt.want {
+				t.Errorf("tagSlug(%q) = %q, want %q", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		tags      []string
+		wantField string // empty when the tags are valid
+		wantText  string
+	}{
+		{"valid", []string{"math", "Algebra 2"}, "", ""},
+		{"none", nil, "tags", "at least one tag"},
+		{"too many", make([]string, maxTagsPerPost+1), "tags", "at most"},
+		{"blank", []string{"math", " "}, "tags[1]", "can't be blank"},
+		{"too long", []string{strings.Repeat("a", maxTagLength+1)}, "tags[0]", "longer than"},
+		{"only punctuation", []string{"math", "!!!"}, "tags[1]", "at least one letter"},
+		{"no ascii letters", []string{"数学"}, "tags[0]", "at least one letter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateTags(tt.tags)
+			if tt.wantField == "" {
+				if len(errs) > 0 {
+					t.Errorf("unexpected errors: %+v", errs)
+				}
+				return
+			}
+			for _, e := range errs {
+				if e.Field == tt.wantField && strings.Contains(e.Message, tt.wantText) {
+					return
+				}
+			}
+			t.Errorf("errors = %+v, want %s containing %q", errs, tt.wantField, tt.wantText)
+		})
+	}
+}
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	importFormatCSV  = "csv"
+	importFormatGIFT = "gift"
+
+	// choiceSeparator splits lists inside one CSV cell: choices, ms answers and fib blanks.
+	choiceSeparator = "|"
+	// fibBlank stands in for the answer box in GIFT missing word questions.
+	fibBlank = "___"
+)
+
+// Line_Error is a problem with the question that starts on Line of the uploaded text.
+type Line_Error struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// parsedQuestion is a question read from an import, with the line it started on.
+type parsedQuestion struct {
+	Line     int             `json:"line"`
+	Question Question_Update `json:"question"`
+}
+
+// parseQuestionText parses an import in the given format and validates every question.
+func parseQuestionText(format string, r io.Reader) ([]parsedQuestion, []Line_Error) {
+	var parsed []parsedQuestion
+	var errs []Line_Error
+	switch format {
+	case importFormatCSV:
+		parsed, errs = parseCSVQuestions(r)
+	case importFormatGIFT:
+		parsed, errs = parseGIFTQuestions(r)
+	default:
+		return nil, []Line_Error{{Message: fmt.Sprintf("format must be %q or %q", importFormatCSV, importFormatGIFT)}}
+	}
+
+	for _, p := range parsed {
+		for _, fieldErr := range validateQuestion(p.Question) {
+			errs = append(errs, Line_Error{Line: p.Line, Field: fieldErr.Field, Message: fieldErr.Message})
+		}
+	}
+	return parsed, errs
+}
+
+// parseCSVQuestions reads one question per row. The header row names the columns, type,
+// message and answer are required, choices, points, penalty and scoring_mode optional.
+// Lists inside a cell are separated by "|": choices, an 'ms' answer, one answer per 'fib'
+// blank. 'mc' and 'ms' answers are 1-based choice numbers or the choice text itself.
+func parseCSVQuestions(r io.Reader) ([]parsedQuestion, []Line_Error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 0 // every row needs as many cells as the header
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, []Line_Error{{Line: 1, Message: "missing header row"}}
+		}
+		return nil, []Line_Error{{Line: 1, Message: "invalid header row: " + err.Error()}}
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	var errs []Line_Error
+	for _, required := range []string{"type", "message", "answer"} {
+		if _, ok := columns[required]; !ok {
+			errs = append(errs, Line_Error{Line: 1, Field: required, Message: "header is missing the " + required + " column"})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var parsed []parsedQuestion
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// a quote error leaves no field positions behind, FieldPos would panic
+			lineErr := Line_Error{Message: err.Error()}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				lineErr.Line = parseErr.StartLine
+			}
+			// the row itself was read fine, so keep going and report every short or long row
+			if errors.Is(err, csv.ErrFieldCount) {
+				lineErr.Message = fmt.Sprintf("row has %d cells but the header has %d, quote cells that contain commas", len(record), len(header))
+				errs = append(errs, lineErr)
+				continue
+			}
+			errs = append(errs, lineErr)
+			break
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		question := Question_Update{
+			Type:         strings.ToLower(cell("type")),
+			Message:      cell("message"),
+			Scoring_mode: cell("scoring_mode"),
+		}
+		if choices := cell("choices"); choices != "" {
+			question.Choices = splitList(choices)
+		}
+		for _, field := range []struct {
+			name string
+			dest **float64
+		}{{"points", &question.Points}, {"penalty", &question.Penalty}} {
+			if value := cell(field.name); value != "" {
+				f, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					errs = append(errs, Line_Error{Line: line, Field: field.name, Message: fmt.Sprintf("%q is not a number", value)})
+					continue
+				}
+				*field.dest = &f
+			}
+		}
+
+		answer := cell("answer")
+		switch question.Type {
+		case "tf":
+			if b, err := strconv.ParseBool(strings.ToLower(answer)); err == nil {
+				question.Answer_tf = &b
+			} else if answer != "" {
+				errs = append(errs, Line_Error{Line: line, Field: "answer", Message: fmt.Sprintf("%q is not true or false", answer)})
+			}
+		case "mc":
+			if answer != "" {
+				if i, ok := choiceIndex(answer, question.Choices); ok {
+					question.Correct_choice = &i
+				} else {
+					errs = append(errs, Line_Error{Line: line, Field: "answer", Message: fmt.Sprintf("%q is not one of the choices", answer)})
+				}
+			}
+		case "ms":
+			for _, a := range splitList(answer) {
+				if i, ok := choiceIndex(a, question.Choices); ok {
+					question.Correct_choices = append(question.Correct_choices, i)
+				} else {
+					errs = append(errs, Line_Error{Line: line, Field: "answer", Message: fmt.Sprintf("%q is not one of the choices", a)})
+				}
+			}
+		case "fib":
+			if answer != "" {
+				question.Correct_answers = splitList(answer)
+			}
+		}
+		parsed = append(parsed, parsedQuestion{Line: line, Question: question})
+	}
+	return parsed, errs
+}
+
+func splitList(cell string) []string {
+	items := strings.Split(cell, choiceSeparator)
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+// choiceIndex resolves an answer given as a 1-based choice number or the choice's text.
+func choiceIndex(answer string, choices []string) (int, bool) {
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+		return n - 1, true
+	}
+	for i, choice := range choices {
+		if strings.EqualFold(choice, answer) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// parseGIFTQuestions reads the Moodle GIFT subset that maps onto our types: true/false
+// ({T}/{F}), multiple choice ({=right ~wrong}), multiple select ({~%50%right ~%-50%wrong})
+// and short answer ({=answer =alternative}, one 'fib' blank). Titles, feedback and format
+// markers are dropped. Questions are separated by blank lines, "//" starts a comment.
+func parseGIFTQuestions(r io.Reader) ([]parsedQuestion, []Line_Error) {
+	var parsed []parsedQuestion
+	var errs []Line_Error
+
+	var block strings.Builder
+	start := 0
+	flush := func() {
+		text := strings.TrimSpace(block.String())
+		block.Reset()
+		if text == "" {
+			return
+		}
+		question, err := parseGIFTQuestion(text)
+		if err != nil {
+			errs = append(errs, Line_Error{Line: start, Message: err.Error()})
+			return
+		}
+		parsed = append(parsed, parsedQuestion{Line: start, Question: question})
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case strings.HasPrefix(trimmed, "//"), strings.HasPrefix(trimmed, "$CATEGORY:"):
+			continue
+		case trimmed == "":
+			flush()
+			continue
+		}
+		if block.Len() == 0 {
+			start = line
+		}
+		block.WriteString(text)
+		block.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, Line_Error{Line: line, Message: err.Error()})
+	}
+	flush()
+	return parsed, errs
+}
+
+// giftSpecial are the characters GIFT lets you escape with a backslash.
+const giftSpecial = `~=#{}:\`
+
+// giftIndex finds the first unescaped c in s, or -1.
+func giftIndex(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(giftSpecial, s[i+1]) >= 0 {
+			i++
+			continue
+		}
+		if s[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// giftUnescape removes escapes and format markers and collapses whitespace.
+func giftUnescape(s string) string {
+	s = strings.TrimSpace(s)
+	for _, marker := range []string{"[html]", "[plain]", "[markdown]", "[moodle]"} {
+		s = strings.TrimPrefix(s, marker)
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(giftSpecial, s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+type giftAnswer struct {
+	correct bool
+	weight  *float64 // from ~%n%, percent of the question's credit
+	text    string
+}
+
+func parseGIFTQuestion(text string) (Question_Update, error) {
+	var question Question_Update
+
+	// ::title:: isn't stored, the message is what gets shown
+	if strings.HasPrefix(text, "::") {
+		end := strings.Index(text[2:], "::")
+		if end < 0 {
+			return question, errors.New("title is missing its closing ::")
+		}
+		text = text[end+4:]
+	}
+
+	open := giftIndex(text, '{')
+	if open < 0 {
+		return question, errors.New("no answer block, essay and description questions are not supported")
+	}
+	closing := giftIndex(text[open:], '}')
+	if closing < 0 {
+		return question, errors.New("answer block is missing its closing }")
+	}
+	closing += open
+	before, answerText, after := text[:open], strings.TrimSpace(text[open+1:closing]), text[closing+1:]
+
+	question.Message = giftUnescape(before)
+	if rest := giftUnescape(after); rest != "" {
+		question.Message = strings.TrimSpace(question.Message + " " + fibBlank + " " + rest)
+	}
+
+	if strings.HasPrefix(answerText, "#") {
+		return question, errors.New("numerical questions are not supported")
+	}
+	switch strings.ToUpper(stripGIFTFeedback(answerText)) {
+	case "T", "TRUE":
+		b := true
+		question.Type, question.Answer_tf = "tf", &b
+		return question, nil
+	case "F", "FALSE":
+		b := false
+		question.Type, question.Answer_tf = "tf", &b
+		return question, nil
+	case "":
+		return question, errors.New("essay questions are not supported")
+	}
+
+	answers, err := splitGIFTAnswers(answerText)
+	if err != nil {
+		return question, err
+	}
+	var wrong, weighted int
+	for _, a := range answers {
+		if !a.correct {
+			wrong++
+		}
+		if a.weight != nil {
+			weighted++
+		}
+		if strings.Contains(a.text, "->") {
+			return question, errors.New("matching questions are not supported")
+		}
+	}
+
+	switch {
+	case wrong == 0:
+		// short answer: every answer is accepted in the one blank
+		if !strings.Contains(question.Message, fibBlank) {
+			question.Message = strings.TrimSpace(question.Message + " " + fibBlank)
+		}
+		question.Type = "fib"
+		question.Correct_answers = []string{answers[0].text}
+		question.Fib_options = &Fib_Options{Case_insensitive: true, Trim_whitespace: true}
+		if len(answers) > 1 {
+			alternatives := make([]string, 0, len(answers)-1)
+			for _, a := range answers[1:] {
+				alternatives = append(alternatives, a.text)
+			}
+			question.Fib_options.Alternatives = [][]string{alternatives}
+		}
+
+	case weighted > 0:
+		question.Type = "ms"
+		question.Scoring_mode = scoringPartial
+		for i, a := range answers {
+			question.Choices = append(question.Choices, a.text)
+			if a.correct || (a.weight != nil && *a.weight > 0) {
+				question.Correct_choices = append(question.Correct_choices, i)
+			}
+		}
+
+	default:
+		question.Type = "mc"
+		for i, a := range answers {
+			question.Choices = append(question.Choices, a.text)
+			if a.correct {
+				if question.Correct_choice != nil {
+					return question, errors.New("multiple choice questions need exactly one =answer, use ~%n% weights for multiple select")
+				}
+				i := i
+				question.Correct_choice = &i
+			}
+		}
+	}
+	return question, nil
+}
+
+// stripGIFTFeedback drops the #feedback after an answer.
+func stripGIFTFeedback(s string) string {
+	if i := giftIndex(s, '#'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// splitGIFTAnswers splits an answer block on unescaped = and ~.
+func splitGIFTAnswers(block string) ([]giftAnswer, error) {
+	var answers []giftAnswer
+	start := -1
+	var marker byte
+	emit := func(end int) error {
+		if start < 0 {
+			if strings.TrimSpace(block[:end]) != "" {
+				return fmt.Errorf("answer %q must start with = or ~", strings.TrimSpace(block[:end]))
+			}
+			return nil
+		}
+		a := giftAnswer{correct: marker == '='}
+		text := stripGIFTFeedback(block[start:end])
+		if strings.HasPrefix(text, "%") {
+			end := strings.Index(text[1:], "%")
+			if end < 0 {
+				return fmt.Errorf("weight in %q is missing its closing %%", text)
+			}
+			weight, err := strconv.ParseFloat(text[1:end+1], 64)
+			if err != nil {
+				return fmt.Errorf("weight in %q is not a number", text)
+			}
+			a.weight = &weight
+			text = text[end+2:]
+		}
+		a.text = giftUnescape(text)
+		answers = append(answers, a)
+		return nil
+	}
+
+	for i := 0; i < len(block); i++ {
+		if block[i] == '\\' && i+1 < len(block) && strings.IndexByte(giftSpecial, block[i+1]) >= 0 {
+			i++
+			continue
+		}
+		if block[i] == '=' || block[i] == '~' {
+			if err := emit(i); err != nil {
+				return nil, err
+			}
+			start, marker = i+1, block[i]
+		}
+	}
+	if err := emit(len(block)); err != nil {
+		return nil, err
+	}
+	if len(answers) == 0 {
+		return nil, errors.New("answer block has no answers")
+	}
+	return answers, nil
+}
+
+// ImportQuestions godoc
+// @Summary      Import questions from CSV or GIFT
+// @Description  Parse questions from a CSV (header row with type, message, choices, answer and optional points, penalty, scoring_mode, lists separated by "|") or Moodle GIFT body and append them after the quiz's existing questions. With dry_run nothing is saved and the parsed questions come back with any errors. Otherwise one bad question means none are added.
+// @Tags         quiz, question
+// @Accept       plain
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true   "Quiz ID"
+// @Param        format   query     string  true   "csv or gift"
+// @Param        dry_run  query     bool    false  "Only parse and validate"
+// @Param        body     body      string  true   "Questions in the given format"
+// @Success      200  {object}  map[string]interface{}  "Dry run: parsed questions and line numbered errors"
+// @Success      201  {object}  map[string]interface{}  "question_id and position of each new question"
+// @Failure      400  {object}  API_Error  "Invalid quiz ID or format"
+// @Failure      403  {object}  API_Error  "Not the creator or a collaborator"
+// @Failure      404  {object}  API_Error  "Quiz not found"
+// @Failure      422  {object}  API_Error  "Line numbered errors, nothing was imported"
+// @Failure      500  {object}  API_Error  "Internal server error"
+// @Router       /question/import/{id} [post]
+func ImportQuestions(c *fiber.Ctx) error {
+	quizIDStr := c.Params("id")
+	quizID, err := uuid.Parse(quizIDStr)
+	if err != nil {
+		return badRequest("Invalid quiz ID")
+	}
+	if err := authorizeQuiz(c, quizID, roleCollaborator); err != nil {
+		return err
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format != importFormatCSV && format != importFormatGIFT {
+		return badRequest("format must be csv or gift")
+	}
+
+	parsed, lineErrs := parseQuestionText(format, strings.NewReader(string(c.Body())))
+	if parsed == nil {
+		parsed = []parsedQuestion{}
+	}
+	if lineErrs == nil {
+		lineErrs = []Line_Error{}
+	}
+
+	if c.QueryBool("dry_run") {
+		return c.JSON(fiber.Map{"questions": parsed, "errors": lineErrs})
+	}
+	if len(lineErrs) > 0 {
+		return validationFailed("Invalid questions", nil).withDetails(fiber.Map{"errors": lineErrs})
+	}
+	if len(parsed) == 0 {
+		return validationFailed("No questions found", nil).withDetails(fiber.Map{"errors": lineErrs})
+	}
+
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		return internalError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	count, err := lockQuizOrder(context.Background(), tx, quizID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notFound("Quiz not found")
+		}
+		return internalError("Failed to get question count", err)
+	}
+
+	created := make([]fiber.Map, len(parsed))
+	for i, p := range parsed {
+		position := count + 1 + i
+		questionID, err := insertQuestion(context.Background(), tx, quizID, position, p.Question)
+		if err != nil {
+			return internalError("Failed to insert new question", err)
+		}
+		created[i] = fiber.Map{"question_id": questionID.String(), "position": position, "line": p.Line}
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return internalError("Failed to commit transaction", err)
+	}
+	return c.Status(201).JSON(created)
+}
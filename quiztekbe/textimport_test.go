@@ -0,0 +1,181 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ptr is for the optional fields of expected values
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestParseCSVQuestions(t *testing.T) {
+	parsed, errs := parseQuestionText(importFormatCSV, strings.NewReader(
+		"type,message,choices,answer,points\n"+
+			"tf,Sky is blue,,true,\n"+
+			"\n"+
+			"mc,Pick two,one|two|three,2,3\n"+
+			"ms,Evens,one|two|three|four,2|four,\n"+
+			"fib,\"Capital of France, ___\",,Paris,\n"))
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+	if len(parsed) != 4 {
+		t.Fatalf("got %d questions, want 4", len(parsed))
+	}
+
+	wantLines := []int{2, 4, 5, 6}
+	for i, p := range parsed {
+		if p.Line != wantLines[i] {
+			t.Errorf("question %d: line %d, want %d", i, p.Line, wantLines[i])
+		}
+	}
+	if q := parsed[0].Question; q.Type != "tf" || q.Answer_tf == nil || !*q.Answer_tf {
+		t.Errorf("tf question parsed as %+v", q)
+	}
+	if q := parsed[1].Question; q.Correct_choice == nil || *q.Correct_choice != 1 || q.Points == nil || *q.Points != 3 {
+		t.Errorf("mc question parsed as %+v", q)
+	}
+	if q := parsed[2].Question; !reflect.DeepEqual(q.Correct_choices, []int{1, 3}) {
+		t.Errorf("ms correct_choices = %v, want [1 3]", q.Correct_choices)
+	}
+	if q := parsed[3].Question; q.Message != "Capital of France, ___" || !reflect.DeepEqual(q.Correct_answers, []string{"Paris"}) {
+		t.Errorf("fib question parsed as %+v", q)
+	}
+}
+
+func TestParseCSVQuestionsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantLine  int
+		wantField string
+		wantText  string
+	}{
+		{"empty body", "", 1, "", "missing header row"},
+		{"missing column", "type,message\ntf,hi\n", 1, "answer", "answer column"},
+		{"bare quote in cell", "type,message,answer\ntf,hi,true\n\"tf\"x,hi,true\n", 3, "", "quote"},
+		{"unterminated quote", "type,message,answer\ntf,hi,true\n\"tf\n,hi,true\n", 3, "", "quote"},
+		{"too many cells", "type,message,answer\ntf,hi, there,true\n", 2, "", "has 4 cells but the header has 3"},
+		{"too few cells", "type,message,answer\ntf,hi\n", 2, "", "has 2 cells but the header has 3"},
+		{"bad tf answer", "type,message,answer\ntf,hi,maybe\n", 2, "answer", "not true or false"},
+		{"unknown choice", "type,message,choices,answer\nmc,hi,a|b,c\n", 2, "answer", "not one of the choices"},
+		{"bad points", "type,message,answer,points\ntf,hi,true,lots\n", 2, "points", "not a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := parseQuestionText(importFormatCSV, strings.NewReader(tt.input))
+			for _, e := range errs {
+				if e.Line == tt.wantLine && e.Field == tt.wantField && strings.Contains(e.Message, tt.wantText) {
+					return
+				}
+			}
+			t.Errorf("want line %d field %q containing %q, got %+v", tt.wantLine, tt.wantField, tt.wantText, errs)
+		})
+	}
+}
+
+func TestParseCSVQuestionsKeepsGoingAfterBadRowLength(t *testing.T) {
+	parsed, errs := parseQuestionText(importFormatCSV, strings.NewReader(
+		"type,message,answer\ntf,one,true\ntf,two\ntf,three,false\n"))
+	if len(errs) != 1 || errs[0].Line != 3 {
+		t.Errorf("errors = %+v, want one on line 3", errs)
+	}
+	if len(parsed) != 2 {
+		t.Errorf("got %d questions, want the 2 good rows", len(parsed))
+	}
+}
+
+func TestParseGIFTQuestion(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Question_Update
+	}{
+		{
+			name:  "true false",
+			input: "Grass is green {T}",
+			want:  Question_Update{Type: "tf", Message: "Grass is green", Answer_tf: ptr(true)},
+		},
+		{
+			name:  "title is dropped",
+			input: "::Q1:: 2 + 2 = 4 {TRUE}",
+			want:  Question_Update{Type: "tf", Message: "2 + 2 = 4", Answer_tf: ptr(true)},
+		},
+		{
+			name:  "multiple choice with feedback",
+			input: "Pick one {=right#yes ~wrong#no ~other}",
+			want:  Question_Update{Type: "mc", Message: "Pick one", Choices: []string{"right", "wrong", "other"}, Correct_choice: ptr(0)},
+		},
+		{
+			name:  "weights make it multiple select",
+			input: "Pick some {~%50%a ~%50%b ~%-100%c}",
+			want: Question_Update{Type: "ms", Message: "Pick some", Choices: []string{"a", "b", "c"},
+				Correct_choices: []int{0, 1}, Scoring_mode: scoringPartial},
+		},
+		{
+			name:  "escaped specials stay in the text",
+			input: `What is 1 \= 1 \~ true? {=yes \= sure ~no}`,
+			want:  Question_Update{Type: "mc", Message: "What is 1 = 1 ~ true?", Choices: []string{"yes = sure", "no"}, Correct_choice: ptr(0)},
+		},
+		{
+			name:  "short answer with alternatives",
+			input: "The capital of France is {=Paris =paris} today.",
+			want: Question_Update{Type: "fib", Message: "The capital of France is ___ today.",
+				Correct_answers: []string{"Paris"},
+				Fib_options:     &Fib_Options{Case_insensitive: true, Trim_whitespace: true, Alternatives: [][]string{{"paris"}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGIFTQuestion(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGIFTQuestionUnsupported(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantText string
+	}{
+		{"numerical", "How many legs? {#4:0}", "numerical"},
+		{"numerical with feedback", "How many legs? {#4#right}", "numerical"},
+		{"essay", "Write about it {}", "essay"},
+		{"matching", "Match {=cat -> meow =dog -> woof}", "matching"},
+		{"no answer block", "Just a description", "no answer block"},
+		{"unclosed title", "::Q1 What? {T}", "title"},
+		{"two right answers", "Pick {=a =b ~c}", "exactly one"},
+		{"bad weight", "Pick {~%abc%a ~b}", "not a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseGIFTQuestion(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.wantText) {
+				t.Errorf("error = %v, want one containing %q", err, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestParseGIFTQuestionsLines(t *testing.T) {
+	input := "// a comment\n$CATEGORY: top\n\n::a:: One {T}\n\nTwo\nlines {F}\n\nThree {#1}\n"
+	parsed, errs := parseQuestionText(importFormatGIFT, strings.NewReader(input))
+	if len(parsed) != 2 || parsed[0].Line != 4 || parsed[1].Line != 6 {
+		t.Errorf("parsed = %+v, want questions on lines 4 and 6", parsed)
+	}
+	if len(parsed) == 2 && parsed[1].Question.Message != "Two lines" {
+		t.Errorf("message = %q, want the lines joined", parsed[1].Question.Message)
+	}
+	if len(errs) != 1 || errs[0].Line != 9 {
+		t.Errorf("errors = %+v, want one on line 9", errs)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Field_Error is one problem with one field of a request body.
+type Field_Error struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// prefixFieldErrors namespaces errors from a nested value, e.g. the index of a question in a batch.
+func prefixFieldErrors(prefix string, errs []Field_Error) []Field_Error {
+	for i := range errs {
+		errs[i].Field = prefix + "." + errs[i].Field
+	}
+	return errs
+}
+
+// validateQuestion checks a question's invariants for its type. It's shared by every
+// path that writes question content so they all agree on what a valid question is.
+func validateQuestion(q Question_Update) []Field_Error {
+	var errs []Field_Error
+	add := func(field, format string, args ...any) {
+		errs = append(errs, Field_Error{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(q.Message) == "" {
+		add("message", "message is required")
+	}
+
+	if q.Points != nil && (*q.Points < 0 || math.IsNaN(*q.Points) || math.IsInf(*q.Points, 0)) {
+		add("points", "points can't be negative")
+	}
+	if q.Penalty != nil && *q.Penalty != 0 {
+		if *q.Penalty < 0 || math.IsNaN(*q.Penalty) || math.IsInf(*q.Penalty, 0) {
+			add("penalty", "penalty can't be negative, it's already taken off the score")
+		} else if !negativeMarking[q.Type] {
+			add("penalty", "penalty only applies to 'tf' and 'mc' questions")
+		}
+	}
+
+	switch q.Type {
+	case "tf":
+		if q.Answer_tf == nil {
+			add("answer_tf", "answer_tf is required for 'tf' questions")
+		}
+
+	case "mc":
+		validateChoices(q.Choices, add)
+		if q.Correct_choice == nil {
+			add("correct_choice", "correct_choice is required for 'mc' questions")
+		} else if *q.Correct_choice < 0 || *q.Correct_choice >= len(q.Choices) {
+			add("correct_choice", "correct_choice %d is out of range for %d choices", *q.Correct_choice, len(q.Choices))
+		}
+
+	case "ms":
+		validateChoices(q.Choices, add)
+		if len(q.Correct_choices) == 0 {
+			add("correct_choices", "at least one correct choice is required for 'ms' questions")
+		}
+		seen := make(map[int]bool, len(q.Correct_choices))
+		for i, choice := range q.Correct_choices {
+			field := fmt.Sprintf("correct_choices[%d]", i)
+			if choice < 0 || choice >= len(q.Choices) {
+				add(field, "index %d is out of range for %d choices", choice, len(q.Choices))
+			} else if seen[choice] {
+				add(field, "index %d is listed more than once", choice)
+			}
+			seen[choice] = true
+		}
+		if q.Scoring_mode != "" && q.Scoring_mode != scoringAllOrNothing && q.Scoring_mode != scoringPartial {
+			add("scoring_mode", "scoring_mode must be %q or %q", scoringAllOrNothing, scoringPartial)
+		}
+
+	case "fib":
+		if len(q.Correct_answers) == 0 {
+			add("correct_answers", "at least one answer is required for 'fib' questions")
+		}
+		for i, answer := range q.Correct_answers {
+			if strings.TrimSpace(answer) == "" {
+				add(fmt.Sprintf("correct_answers[%d]", i), "answer can't be blank")
+			}
+		}
+		if q.Fib_options != nil {
+			validateFibOptions(q.Fib_options, q.Correct_answers, add)
+		}
+
+	default:
+		add("type", "type must be one of 'tf', 'mc', 'ms' or 'fib'")
+	}
+
+	return errs
+}
+
+// validateQuizUpdate checks the fields a quiz PATCH sends. A time_limit of 0 is allowed
+// there, it makes the quiz untimed.
+func validateQuizUpdate(quizUpdate Quiz_Update) []Field_Error {
+	var fieldErrs []Field_Error
+	if quizUpdate.Title != nil && strings.TrimSpace(*quizUpdate.Title) == "" {
+		fieldErrs = append(fieldErrs, Field_Error{Field: "title", Message: "title can't be empty"})
+	}
+	if quizUpdate.Time_limit != nil && *quizUpdate.Time_limit != 0 {
+		fieldErrs = append(fieldErrs, validateTimeLimit(quizUpdate.Time_limit)...)
+	}
+	return fieldErrs
+}
+
+// validateTimeLimit checks an optional quiz time limit, in seconds.
+func validateTimeLimit(timeLimit *int) []Field_Error {
+	if timeLimit != nil && *timeLimit <= 0 {
+		return []Field_Error{{Field: "time_limit", Message: "time_limit must be a positive number of seconds"}}
+	}
+	return nil
+}
+
+func validateChoices(choices []string, add func(field, format string, args ...any)) {
+	if len(choices) < 2 {
+		add("choices", "at least 2 choices are required")
+	}
+	for i, choice := range choices {
+		if strings.TrimSpace(choice) == "" {
+			add(fmt.Sprintf("choices[%d]", i), "choice can't be blank")
+		}
+	}
+}
+
+func validateFibOptions(o *Fib_Options, correctAnswers []string, add func(field, format string, args ...any)) {
+	if len(o.Alternatives) > len(correctAnswers) {
+		add("fib_options.alternatives", "has %d entries but there are only %d blanks", len(o.Alternatives), len(correctAnswers))
+	}
+	if o.Numeric_tolerance != nil && (*o.Numeric_tolerance < 0 || math.IsNaN(*o.Numeric_tolerance)) {
+		add("fib_options.numeric_tolerance", "numeric_tolerance can't be negative")
+	}
+	if !o.Regex {
+		return
+	}
+	for i, pattern := range correctAnswers {
+		if _, err := o.compile(pattern); err != nil {
+			add(fmt.Sprintf("correct_answers[%d]", i), "invalid regex %q", pattern)
+		}
+	}
+	for i, alternatives := range o.Alternatives {
+		for j, pattern := range alternatives {
+			if _, err := o.compile(pattern); err != nil {
+				add(fmt.Sprintf("fib_options.alternatives[%d][%d]", i, j), "invalid regex %q", pattern)
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestValidateQuestion(t *testing.T) {
+	tests := []struct {
+		name      string
+		question  Question_Update
+		wantField string // empty when the question is valid
+		wantText  string
+	}{
+		{"valid tf", Question_Update{Type: "tf", Message: "Sky is blue", Answer_tf: ptr(true)}, "", ""},
+		{"valid mc", Question_Update{Type: "mc", Message: "Pick", Choices: []string{"a", "b"}, Correct_choice: ptr(1), Penalty: ptr(0.5)}, "", ""},
+		{"valid ms", Question_Update{Type: "ms", Message: "Pick", Choices: []string{"a", "b", "c"}, Correct_choices: []int{0, 2}, Scoring_mode: scoringPartial}, "", ""},
+		{"valid fib", Question_Update{Type: "fib", Message: "___ is red", Correct_answers: []string{"Mars"}}, "", ""},
+		{"zero penalty on ms", Question_Update{Type: "ms", Message: "Pick", Choices: []string{"a", "b"}, Correct_choices: []int{0}, Penalty: ptr(0.0)}, "", ""},
+
+		{"blank message", Question_Update{Type: "tf", Message: "  ", Answer_tf: ptr(true)}, "message", "message is required"},
+		{"unknown type", Question_Update{Type: "essay", Message: "Why?"}, "type", "type must be one of"},
+		{"negative points", Question_Update{Type: "tf", Message: "Q", Answer_tf: ptr(true), Points: ptr(-1.0)}, "points", "can't be negative"},
+		{"NaN points", Question_Update{Type: "tf", Message: "Q", Answer_tf: ptr(true), Points: ptr(math.NaN())}, "points", "can't be negative"},
+		{"negative penalty", Question_Update{Type: "tf", Message: "Q", Answer_tf: ptr(true), Penalty: ptr(-1.0)}, "penalty", "can't be negative"},
+		{"penalty on fib", Question_Update{Type: "fib", Message: "Q", Correct_answers: []string{"a"}, Penalty: ptr(1.0)}, "penalty", "only applies to 'tf' and 'mc'"},
+
+		{"tf without answer", Question_Update{Type: "tf", Message: "Q"}, "answer_tf", "answer_tf is required"},
+
+		{"mc with one choice", Question_Update{Type: "mc", Message: "Q", Choices: []string{"a"}, Correct_choice: ptr(0)}, "choices", "at least 2 choices"},
+		{"mc blank choice", Question_Update{Type: "mc", Message: "Q", Choices: []string{"a", " "}, Correct_choice: ptr(0)}, "choices[1]", "can't be blank"},
+		{"mc without answer", Question_Update{Type: "mc", Message: "Q", Choices: []string{"a", "b"}}, "correct_choice", "is required"},
+		{"mc answer out of range", Question_Update{Type: "mc", Message: "Q", Choices: []string{"a", "b"}, Correct_choice: ptr(2)}, "correct_choice", "out of range for 2 choices"},
+
+		{"ms without answers", Question_Update{Type: "ms", Message: "Q", Choices: []string{"a", "b"}}, "correct_choices", "at least one correct choice"},
+		{"ms answer out of range", Question_Update{Type: "ms", Message: "Q", Choices: []string{"a", "b"}, Correct_choices: []int{0, 5}}, "correct_choices[1]", "index 5 is out of range"},
+		{"ms duplicate answer", Question_Update{Type: "ms", Message: "Q", Choices: []string{"a", "b"}, Correct_choices: []int{1, 1}}, "correct_choices[1]", "more than once"},
+		{"ms bad scoring mode", Question_Update{Type: "ms", Message: "Q", Choices: []string{"a", "b"}, Correct_choices: []int{0}, Scoring_mode: "generous"}, "scoring_mode", "scoring_mode must be"},
+
+		{"fib without answers", Question_Update{Type: "fib", Message: "Q"}, "correct_answers", "at least one answer"},
+		{"fib blank answer", Question_Update{Type: "fib", Message: "Q", Correct_answers: []string{"a", ""}}, "correct_answers[1]", "can't be blank"},
+		{
+			"fib options are checked",
+			Question_Update{Type: "fib", Message: "Q", Correct_answers: []string{"a"}, Fib_options: &Fib_Options{Numeric_tolerance: ptr(-1.0)}},
+			"fib_options.numeric_tolerance", "can't be negative",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateQuestion(tt.question)
+			if tt.wantField == "" {
+				if len(errs) > 0 {
+					t.Errorf("unexpected errors: %+v", errs)
+				}
+				return
+			}
+			for _, e := range errs {
+				if e.Field == tt.wantField && strings.Contains(e.Message, tt.wantText) {
+					return
+				}
+			}
+			t.Errorf("want %s containing %q, got %+v", tt.wantField, tt.wantText, errs)
+		})
+	}
+}
+
+func TestValidateQuestionReportsEveryProblem(t *testing.T) {
+	errs := validateQuestion(Question_Update{Type: "mc", Choices: []string{""}, Correct_choice: ptr(3), Points: ptr(-1.0)})
+	fields := map[string]bool{}
+	for _, e := range errs {
+		fields[e.Field] = true
+	}
+	for _, want := range []string{"message", "points", "choices", "choices[0]", "correct_choice"} {
+		if !fields[want] {
+			t.Errorf("no error for %s in %+v", want, errs)
+		}
+	}
+}
+
+func TestValidateFibOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    Fib_Options
+		answers    []string
+		wantFields []string
+	}{
+		{"defaults", Fib_Options{}, []string{"a"}, nil},
+		{"one alternative list per blank", Fib_Options{Alternatives: [][]string{{"b"}, {"c"}}}, []string{"a", "b"}, nil},
+		{"more alternative lists than blanks", Fib_Options{Alternatives: [][]string{{"b"}, {"c"}}}, []string{"a"}, []string{"fib_options.alternatives"}},
+		{"zero tolerance", Fib_Options{Numeric_tolerance: ptr(0.0)}, []string{"1"}, nil},
+		{"negative tolerance", Fib_Options{Numeric_tolerance: ptr(-0.5)}, []string{"1"}, []string{"fib_options.numeric_tolerance"}},
+		{"NaN tolerance", Fib_Options{Numeric_tolerance: ptr(math.NaN())}, []string{"1"}, []string{"fib_options.numeric_tolerance"}},
+		{"valid regex", Fib_Options{Regex: true, Alternatives: [][]string{{"gr[ae]y"}}}, []string{"colou?r"}, nil},
+		{"bad regex answer", Fib_Options{Regex: true}, []string{"ok", "(unclosed"}, []string{"correct_answers[1]"}},
+		{"bad regex alternative", Fib_Options{Regex: true, Alternatives: [][]string{{"ok", "[z-a]"}}}, []string{"a"}, []string{"fib_options.alternatives[0][1]"}},
+		{"regex not checked when off", Fib_Options{}, []string{"(unclosed"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			validateFibOptions(&tt.options, tt.answers, func(field, format string, args ...any) {
+				fields = append(fields, field)
+				if msg := fmt.Sprintf(format, args...); msg == "" {
+					t.Errorf("empty message for %s", field)
+				}
+			})
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("errors on %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestValidateQuizUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		update     Quiz_Update
+		wantFields []string
+	}{
+		{"nothing sent", Quiz_Update{}, nil},
+		{"new title", Quiz_Update{Title: ptr("Capitals")}, nil},
+		{"clear time limit", Quiz_Update{Time_limit: ptr(0)}, nil},
+		{"new time limit", Quiz_Update{Time_limit: ptr(600)}, nil},
+		{"uncategorise", Quiz_Update{Category: ptr("")}, nil},
+
+		{"blank title", Quiz_Update{Title: ptr("  ")}, []string{"title"}},
+		{"negative time limit", Quiz_Update{Time_limit: ptr(-5)}, []string{"time_limit"}},
+		{"both wrong", Quiz_Update{Title: ptr(""), Time_limit: ptr(-1)}, []string{"title", "time_limit"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateQuizUpdate(tt.update)
+			if len(errs) != len(tt.wantFields) {
+				t.Fatalf("errors = %+v, want fields %v", errs, tt.wantFields)
+			}
+			for i, e := range errs {
+				if e.Field != tt.wantFields[i] {
+					t.Errorf("errors[%d].Field = %q, want %q", i, e.Field, tt.wantFields[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+"use client";
+
+const apiBaseUrl = process.env.NEXT_PUBLIC_API_URL || "http://localhost:3001";
+
+// the bearer token from /auth/login or /auth/register, kept across tabs and reloads
+const tokenKey = "quiztek_token";
+const userKey = "quiztek_user";
+
+export function getToken() {
+    return localStorage.getItem(tokenKey);
+}
+
+export function getUser() {
+    const user = localStorage.getItem(userKey);
+    return user ? JSON.parse(user) : null;
+}
+
+export function saveSession(token, user) {
+    localStorage.setItem(tokenKey, token);
+    localStorage.setItem(userKey, JSON.stringify(user));
+}
+
+export function clearSession() {
+    localStorage.removeItem(tokenKey);
+    localStorage.removeItem(userKey);
+}
+
+// fetch for everything that needs a login: adds the token, and sends the user to the
+// login page when there isn't one or the backend says it expired
+export async function authFetch(url, options: RequestInit = {}) {
+    const token = getToken();
+    const headers = new Headers(options.headers);
+    if (token) headers.set("Authorization", `Bearer ${token}`);
+
+    const res = await fetch(url, {...options, headers});
+    if (res.status === 401) {
+        clearSession();
+        window.location.href = `/login?next=${encodeURIComponent(window.location.pathname)}`;
+    }
+    return res;
+}
+
+export async function logout() {
+    const token = getToken();
+    clearSession();
+    if (!token) return;
+    try {
+        await fetch(`${apiBaseUrl}/auth/logout`, {
+            method: "POST",
+            headers: {Authorization: `Bearer ${token}`},
+        });
+    } catch (err) {
+        console.error("Error logging out:", err); // the token is forgotten either way
+    }
+}
@@ -3,6 +3,7 @@ import React from 'react';
 import {Info} from 'lucide-react';
 import Link from "next/link";
 import {motion} from "framer-motion";
+import {authFetch} from "@/app/Components/auth";
 
 const apiBaseUrl = process.env.NEXT_PUBLIC_API_URL || "http://localhost:3001";
 
@@ -16,7 +17,7 @@ function Card(props) {
     const noSubmissionYet = true;
     const deleteQuiz = async (quiz_id) => {
         try {
-            const response = await fetch(`${apiBaseUrl}/quiz/delete/${quiz_id}`, {
+            const response = await authFetch(`${apiBaseUrl}/quiz/delete/${quiz_id}`, {
                 method: "DELETE",
             });
             if (!response.ok) {
@@ -0,0 +1,22 @@
+const apiBaseUrl = process.env.NEXT_PUBLIC_API_URL || "http://localhost:3001";
+
+// what GET /category answers with, see Category in quiztekbe/model.go
+export type Category = {
+    id: string;
+    slug: string;
+    name: string;
+    parent_id: string | null;
+    created_by: string | null;
+    created_at: string;
+    quiz_count: number;
+    total_quiz_count: number;
+};
+
+// every category, flat, for picking one when creating or editing a quiz
+export async function fetchCategories(): Promise<Category[]> {
+    const res = await fetch(`${apiBaseUrl}/category`);
+    if (!res.ok) {
+        throw new Error("Failed to fetch categories");
+    }
+    return res.json();
+}
@@ -1,11 +1,23 @@
 "use client";
-import React from 'react';
+import React, {useEffect, useState} from 'react';
 import Image from 'next/image'
 import {motion} from "framer-motion"
 import Link from "next/link";
+import {getUser, logout} from "@/app/Components/auth";
 
 function Navbar() {
-    const loggedIn = false;
+    const [user, setUser] = useState(null);
+
+    // localStorage only exists in the browser, so read it after the first render
+    useEffect(() => {
+        setUser(getUser());
+    }, []);
+
+    const handleLogout = async () => {
+        await logout();
+        window.location.href = "/";
+    };
+
     return (
         <div className="flex bg-white w-full h-20 justify-between ">
             <Link href="/">
@@ -16,7 +28,12 @@ function Navbar() {
                 </motion.div>
             </Link>
             <div className="p-8 flex gap-20 text-sm">
-                {loggedIn ? (<div>Logout</div>) : (<div className="">Login</div>)}
+                {user ? (
+                    <div className="flex gap-6">
+                        <div className="text-gray-500">{user.email}</div>
+                        <div onClick={handleLogout} className="cursor-pointer">Logout</div>
+                    </div>
+                ) : (<Link href="/login" className="">Login</Link>)}
             </div>
 
 
@@ -1,6 +1,7 @@
 "use client";
 import React, { use, useEffect, useState } from 'react';
 import Link from "next/link";
+import {authFetch} from "@/app/Components/auth";
 
 const apiBaseUrl = process.env.NEXT_PUBLIC_API_URL || "http://localhost:3001";
 
@@ -104,7 +105,7 @@ function Page(props) {
         }
 
         try {
-            const res = await fetch(`${apiBaseUrl}/question/edit/${questionId}`, {
+            const res = await authFetch(`${apiBaseUrl}/question/edit/${questionId}`, {
                 method: "PATCH",
                 headers: {
                     "Content-Type": "application/json",
@@ -138,7 +139,7 @@ function Page(props) {
 
     const handleDelete = async () => {
         try {
-            const response = await fetch(`${apiBaseUrl}/question/delete/${questionId}`, {
+            const response = await authFetch(`${apiBaseUrl}/question/delete/${questionId}`, {
                 method: 'DELETE',
             });
             if (response.ok) {
@@ -2,13 +2,16 @@
 import React, {use, useEffect, useState} from 'react';
 import Link from "next/link";
 import {Plus} from "lucide-react";
+import {authFetch} from "@/app/Components/auth";
+import {Category, fetchCategories} from "@/app/Components/category";
 
 const apiBaseUrl = process.env.NEXT_PUBLIC_API_URL || "http://localhost:3001";
 
 function Page(props) {
     const { quizId } = use(props.params)
     const [title, setTitle] = useState('');
-    const [category, setCategory] = useState('');
+    const [categoryId, setCategoryId] = useState('');
+    const [categories, setCategories] = useState<Category[]>([]);
     const [quizDetail, setQuizDetail] = useState([]);
     const [questionIds,setQuestionIds] = useState([]);
 
@@ -40,22 +43,30 @@ function Page(props) {
         }
     };
 
+    const loadCategories = async () => {
+        try {
+            setCategories(await fetchCategories());
+        } catch (err) {
+            console.error("Error fetching categories:", err);
+        }
+    };
+
     useEffect(() => {
         fetchQuestionIds()
         fetchQuizDetail()
+        loadCategories()
     }, []);
 
     const handleSubmitChanges = async (e) => {
         e.preventDefault();
 
+        // the PATCH only changes what's sent, so leave out anything that wasn't touched
         const data = {};
-        data.title = title.trim()
-        data.category = category.trim()
-        if (data.title === '') data.title = quizDetail.title;
-        if (data.category === '') data.category = quizDetail.category;
+        if (title.trim() !== '') data.title = title.trim();
+        if (categoryId !== '') data.category_id = categoryId;
 
         try {
-            const res = await fetch(`${apiBaseUrl}/quiz/edit/${quizId}`, {
+            const res = await authFetch(`${apiBaseUrl}/quiz/edit/${quizId}`, {
                 method: 'PATCH',
                 headers: {
                     'Content-Type': 'application/json',
@@ -77,7 +88,7 @@ function Page(props) {
 
     const handleClickCreateQuestion = async () => {
         try {
-            const response = await fetch(`${apiBaseUrl}/question/create/${quizId}`, {
+            const response = await authFetch(`${apiBaseUrl}/question/create/${quizId}`, {
                 method: "POST",
             });
 
@@ -112,13 +123,17 @@ function Page(props) {
                         className="border-3 border-[#5038bc] rounded-sm bg-white w-full h-12 px-3 text-xl"
                     />
                     <label className="text-4xl font-medium">Change category</label>
-                    <div
+                    <select
                         id="category"
-                        type="text"
-                        value={category}
-                        onChange={(e) => setCategory(e.target.value)}
+                        value={categoryId}
+                        onChange={(e) => setCategoryId(e.target.value)}
                         className="border-3 border-[#5038bc] rounded-sm bg-white w-full h-12 px-3 text-xl"
-                    />
+                    >
+                        <option value="">Keep {quizDetail.category || "uncategorised"}</option>
+                        {categories.map((category) => (
+                            <option key={category.id} value={category.id}>{category.name}</option>
+                        ))}
+                    </select>
                     <button
                         type="submit"
                         className="text-white bg-[#5038bc] p-2 w-full text-2xl rounded-md cursor-pointer"
@@ -0,0 +1,78 @@
+"use client";
+import React, {useState} from "react";
+import {saveSession} from "@/app/Components/auth";
+
+const apiBaseUrl = process.env.NEXT_PUBLIC_API_URL || "http://localhost:3001";
+
+function Page() {
+    const [mode, setMode] = useState("login"); // or "register"
+    const [email, setEmail] = useState("");
+    const [password, setPassword] = useState("");
+    const [error, setError] = useState("");
+
+    const handleSubmit = async (e) => {
+        e.preventDefault();
+        setError("");
+
+        try {
+            const res = await fetch(`${apiBaseUrl}/auth/${mode}`, {
+                method: "POST",
+                headers: {
+                    "Content-Type": "application/json",
+                },
+                body: JSON.stringify({email, password}),
+            });
+            const data = await res.json();
+            if (!res.ok) {
+                setError(data.error || "Something went wrong");
+                return;
+            }
+
+            saveSession(data.token, data.user);
+            // only follow relative paths, so the link can't send people off site
+            const next = new URLSearchParams(window.location.search).get("next");
+            window.location.href = next && next.startsWith("/") && !next.startsWith("//") ? next : "/";
+        } catch (err) {
+            console.error(`Error on ${mode}:`, err);
+            setError("Couldn't reach the server");
+        }
+    };
+
+    return (
+        <form onSubmit={handleSubmit} className="flex justify-center h-[75vh] items-center">
+            <div className="flex flex-col gap-3 w-[75%] sm:w-[30%]">
+                <div className="text-4xl sm:text-5xl font-semibold mb-4">
+                    {mode === "login" ? "Login" : "Register"}
+                </div>
+                <label className="text-2xl sm:text-3xl font-medium">Email</label>
+                <input
+                    type="email"
+                    value={email}
+                    onChange={(e) => setEmail(e.target.value)}
+                    required
+                    className="border-3 border-[#5038bc] rounded-sm bg-white w-full h-12 px-3 text-xl"
+                />
+                <label className="text-2xl sm:text-3xl font-medium">Password</label>
+                <input
+                    type="password"
+                    value={password}
+                    onChange={(e) => setPassword(e.target.value)}
+                    required
+                    className="border-3 border-[#5038bc] rounded-sm bg-white w-full h-12 px-3 text-xl"
+                />
+                {error !== "" && <div className="text-red-500">{error}</div>}
+                <button type="submit"
+                        className="text-white bg-[#5038bc] p-2 w-full text-2xl rounded-md cursor-pointer">
+                    {mode === "login" ? "Login" : "Create Account"}
+                </button>
+                <button type="button"
+                        onClick={() => setMode(mode === "login" ? "register" : "login")}
+                        className="text-[#5038bc] cursor-pointer">
+                    {mode === "login" ? "No account yet? Register" : "Already have an account? Login"}
+                </button>
+            </div>
+        </form>
+    );
+}
+
+export default Page;
@@ -5,6 +5,8 @@ import {motion} from "framer-motion";
 import { useEffect, useState } from "react";
 import Link from "next/link";
 import {Plus, Search} from "lucide-react";
+import {authFetch} from "@/app/Components/auth";
+import {Category, fetchCategories} from "@/app/Components/category";
 
 const apiBaseUrl = process.env.NEXT_PUBLIC_API_URL || "http://localhost:3001";
 
@@ -14,12 +16,23 @@ export default function Home() {
     const [quizzes, setQuizzes] = useState([]);
     const [isHovered, setIsHovered] = useState(false);
     const [title, setTitle] = useState('');
-    const [category, setCategory] = useState('');
+    const [categoryId, setCategoryId] = useState('');
+    const [categories, setCategories] = useState<Category[]>([]);
 
     useEffect(() => {
         fetchQuizzes();
+        loadCategories();
     }, []);
 
+    // quizzes go in an existing category, new ones are made through POST /category/create
+    const loadCategories = async () => {
+        try {
+            setCategories(await fetchCategories());
+        } catch (err) {
+            console.error("Error fetching categories:", err);
+        }
+    };
+
     const fetchQuizzes = async () => {
         let url = `${apiBaseUrl}/quiz`;
         if (search.trim() !== "") {
@@ -32,7 +45,7 @@ export default function Home() {
                 throw new Error("Failed to fetch quizzes");
             }
             const data = await res.json();
-            setQuizzes(data);
+            setQuizzes(data.quizzes);
         } catch (err) {
             console.error("Error fetching quizzes:", err);
         }
@@ -54,10 +67,10 @@ export default function Home() {
     const handleSubmitForm = async (e) => {
         e.preventDefault();
 
-        const data = { title, category };
+        const data = { title, category_id: categoryId };
 
         try {
-            const res = await fetch(`${apiBaseUrl}/quiz/create`, {
+            const res = await authFetch(`${apiBaseUrl}/quiz/create`, {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json',
@@ -70,7 +83,7 @@ export default function Home() {
             }
 
             setTitle('');
-            setCategory('');
+            setCategoryId('');
             fetchQuizzes()
         } catch (error) {
             console.error('Error submitting form:', error);
@@ -197,13 +210,17 @@ export default function Home() {
                            className="border-3 border-[#5038bc] rounded-sm bg-white w-full h-12 px-3 text-xl"
                     />
                     <label className="text-3xl sm:text-4xl font-medium">Quiz Category</label>
-                    <input id="category"
-                           type="text"
-                           value={category}
-                           onChange={(e) => setCategory(e.target.value)}
-                           required
-                           className="border-3 border-[#5038bc] rounded-sm bg-white w-full h-12 px-3 text-xl"
-                    />
+                    <select id="category"
+                            value={categoryId}
+                            onChange={(e) => setCategoryId(e.target.value)}
+                            required
+                            className="border-3 border-[#5038bc] rounded-sm bg-white w-full h-12 px-3 text-xl"
+                    >
+                        <option value="" disabled>Choose a category</option>
+                        {categories.map((category) => (
+                            <option key={category.id} value={category.id}>{category.name}</option>
+                        ))}
+                    </select>
                     <button type="submit"
                             className="text-white bg-[#5038bc] p-2 w-full text-2xl rounded-md cursor-pointer">Create
                         Quiz
@@ -43,6 +43,13 @@ function Page(props) {
             const res = await fetch(
                 `${apiBaseUrl}/submission/${attemptId}/${questionId}`
             );
+            if (res.status === 404) { // not answered yet
+                setUserAnswer("");
+                return;
+            }
+            if (!res.ok) {
+                throw new Error("Failed to fetch answer");
+            }
             const data = await res.json();
             if (data && Object.keys(data).length > 0) {
                 if (question.type === "tf") {